@@ -1,11 +1,16 @@
 package breadTypes
 
 type Config struct {
-	Package            Package           `toml:"package"`
-	BreadConfig        BreadConfig       `toml:"bread"`
-	Dependencies       map[string]string `toml:"dependencies"`
-	ServerDependencies map[string]string `toml:"server-dependencies"`
-	DevDependencies    map[string]string `toml:"dev-dependencies"`
+	Package            Package               `toml:"package"`
+	BreadConfig        BreadConfig           `toml:"bread"`
+	Dependencies       map[string]Dependency `toml:"dependencies"`
+	ServerDependencies map[string]Dependency `toml:"server-dependencies"`
+	DevDependencies    map[string]Dependency `toml:"dev-dependencies"`
+	Workspace          *Workspace            `toml:"workspace,omitempty"`
+	Overrides          map[string]string     `toml:"overrides,omitempty"` // "scope/name" -> version forced across the graph
+	Place              *Place                `toml:"place,omitempty"`
+	Scripts            map[string]string     `toml:"scripts,omitempty"` // name -> shell command, run with bread run
+	Tools              map[string]string     `toml:"tools,omitempty"`   // name -> "owner/repo@version" GitHub release
 }
 
 type Package struct {
@@ -26,4 +31,31 @@ type BreadConfig struct {
 	PackagesDir string `toml:"shared_dir"`
 	ServerDir   string `toml:"server_dir"`
 	DevDir      string `toml:"dev_dir"`
+	Target      string `toml:"target,omitempty"`       // runtime of the link files: roblox (default), luau or lune
+	RojoProject string `toml:"rojo_project,omitempty"` // project file kept in sync on install, e.g. "default.project.json"
+	Sourcemap   bool   `toml:"sourcemap,omitempty"`    // write sourcemap.json on install
+
+	LuaurcAliases  bool `toml:"luaurc_aliases,omitempty"`  // alias every package folder in .luaurc on install, e.g. @Packages
+	LuaurcPackages bool `toml:"luaurc_packages,omitempty"` // alias every installed package too, e.g. @Signal
+
+	AllowScripts []string `toml:"allow_scripts,omitempty"` // dependencies ("scope/name") whose postinstall may run
+	ToolsURL     string   `toml:"tools_url,omitempty"`     // GitHub API to fetch [tools] releases from, https://api.github.com by default
+
+	InstallExclude []string `toml:"install_exclude,omitempty"` // globs left out when packages are extracted, e.g. "tests/**", a dependency's exclude replaces them
+
+	// limits on package archives, 0 keeps the default
+	MaxExtractFiles int   `toml:"max_extract_files,omitempty"` // entries, 10000 by default
+	MaxExtractBytes int64 `toml:"max_extract_bytes,omitempty"` // total uncompressed size, 256 MB by default
+	MaxExtractRatio int   `toml:"max_extract_ratio,omitempty"` // uncompressed / compressed size, 100 by default
+}
+
+type Workspace struct {
+	Members []string `toml:"members"` // globs of member package directories
+}
+
+// Place is the [place] table of wally.toml, where the package folders live in the game
+type Place struct {
+	SharedPackages string `toml:"shared-packages,omitempty"` // e.g. "game.ReplicatedStorage.Packages"
+	ServerPackages string `toml:"server-packages,omitempty"`
+	DevPackages    string `toml:"dev-packages,omitempty"` // bread only, Wally has no place for dev packages
 }
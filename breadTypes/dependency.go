@@ -0,0 +1,254 @@
+package breadTypes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dependency is one entry of a dependency table.
+// Registry packages use the plain "scope/name@constraint" string or a table like
+// { package = "scope/name", version = "^1.0" }, local packages use { path = "../foo" }
+// and git packages { git = "https://.../foo.git", tag = "v1.2.0" }.
+// Registry and git tables can set exclude = ["tests/**"] in place of [bread] install_exclude.
+type Dependency struct {
+	Spec     string // "scope/name@constraint" when written as a string, empty for the table form
+	Package  string // "scope/name"
+	Version  string // version constraint
+	Realm    string // overrides the realm of the table the dependency is listed in
+	Registry string // package index, defaults to the wally index
+	Optional bool   // a failed install only warns
+
+	Exclude []string // globs left out when the package is extracted, nil when not set
+
+	Path   string // local package directory, relative to the manifest
+	Git    string // repository url
+	Rev    string // any revision git understands
+	Branch string
+	Tag    string
+}
+
+// IsPath reports whether the dependency points at a local checkout
+func (d Dependency) IsPath() bool {
+	return d.Path != ""
+}
+
+// IsGit reports whether the dependency is fetched from a git repository
+func (d Dependency) IsGit() bool {
+	return d.Git != ""
+}
+
+// IsRegistry reports whether the dependency is resolved through the registry
+func (d Dependency) IsRegistry() bool {
+	return !d.IsPath() && !d.IsGit()
+}
+
+// IsTable reports whether the dependency is written as an inline table
+func (d Dependency) IsTable() bool {
+	return d.Spec == ""
+}
+
+// GitRef returns the kind and name of the requested git reference.
+// An empty kind means the default branch.
+func (d Dependency) GitRef() (kind, ref string) {
+	switch {
+	case d.Rev != "":
+		return "rev", d.Rev
+	case d.Tag != "":
+		return "tag", d.Tag
+	case d.Branch != "":
+		return "branch", d.Branch
+	}
+	return "", ""
+}
+
+// Source returns the lockfile source of the dependency, empty for packages from the default registry.
+// Git sources follow the cargo style "git+<url>?tag=v1.2.0"; the locked commit is appended as "#<hash>".
+func (d Dependency) Source() string {
+	switch {
+	case d.IsPath():
+		return "path+" + d.Path
+	case d.IsGit():
+		if kind, ref := d.GitRef(); kind != "" {
+			return "git+" + d.Git + "?" + kind + "=" + ref
+		}
+		return "git+" + d.Git
+	case d.Registry != "":
+		return "registry+" + d.Registry
+	}
+	return ""
+}
+
+// String returns the form used in lockfile dependency lists
+func (d Dependency) String() string {
+	switch {
+	case !d.IsRegistry():
+		return d.Source()
+	case d.Spec != "":
+		return d.Spec
+	case d.Version != "":
+		return d.Package + "@" + d.Version
+	}
+	return d.Package
+}
+
+func (d *Dependency) UnmarshalTOML(data any) error {
+	switch v := data.(type) {
+	case string:
+		d.Spec = v
+		return nil
+	case map[string]any:
+		for key, value := range v {
+			switch key {
+			case "optional":
+				optional, ok := value.(bool)
+				if !ok {
+					return fmt.Errorf("dependency key %q must be a boolean", key)
+				}
+				d.Optional = optional
+				continue
+			case "exclude":
+				globs, ok := value.([]any)
+				if !ok {
+					return fmt.Errorf("dependency key %q must be an array of strings", key)
+				}
+				d.Exclude = make([]string, 0, len(globs))
+				for _, glob := range globs {
+					str, ok := glob.(string)
+					if !ok {
+						return fmt.Errorf("dependency key %q must be an array of strings", key)
+					}
+					d.Exclude = append(d.Exclude, str)
+				}
+				continue
+			}
+
+			str, ok := value.(string)
+			if !ok {
+				return fmt.Errorf("dependency key %q must be a string", key)
+			}
+
+			switch key {
+			case "package":
+				d.Package = str
+			case "version":
+				d.Version = str
+			case "realm":
+				d.Realm = str
+			case "registry":
+				d.Registry = str
+			case "path":
+				d.Path = str
+			case "git":
+				d.Git = str
+			case "rev":
+				d.Rev = str
+			case "branch":
+				d.Branch = str
+			case "tag":
+				d.Tag = str
+			default:
+				return fmt.Errorf("unknown dependency key %q", key)
+			}
+		}
+		return d.validate()
+	default:
+		return fmt.Errorf("unsupported dependency value: %v", data)
+	}
+}
+
+func (d Dependency) validate() error {
+	switch {
+	case d.IsPath() && d.IsGit():
+		return fmt.Errorf("dependency can't have both path and git")
+	case d.IsRegistry() && d.Package == "":
+		return fmt.Errorf("dependency table must have a package, path or git")
+	case !d.IsRegistry() && (d.Package != "" || d.Version != "" || d.Registry != ""):
+		return fmt.Errorf("package, version and registry only apply to registry dependencies")
+	case d.IsPath() && d.Exclude != nil:
+		return fmt.Errorf("exclude doesn't apply to path dependencies, they aren't extracted")
+	}
+
+	switch d.Realm {
+	case "", "shared", "server", "dev":
+	default:
+		return fmt.Errorf("unknown realm %q", d.Realm)
+	}
+
+	refs := 0
+	for _, ref := range []string{d.Rev, d.Branch, d.Tag} {
+		if ref != "" {
+			refs++
+		}
+	}
+	if refs > 0 && !d.IsGit() {
+		return fmt.Errorf("rev, branch and tag only apply to git dependencies")
+	}
+	if refs > 1 {
+		return fmt.Errorf("only one of rev, branch or tag can be set")
+	}
+	return nil
+}
+
+// MarshalTOML writes the dependency back in the form it was read in
+func (d Dependency) MarshalTOML() ([]byte, error) {
+	if !d.IsTable() {
+		return []byte(QuoteTOML(d.Spec)), nil
+	}
+
+	var fields []string
+	add := func(key, value string) {
+		if value != "" {
+			fields = append(fields, key+" = "+QuoteTOML(value))
+		}
+	}
+
+	add("package", d.Package)
+	add("version", d.Version)
+	add("registry", d.Registry)
+	add("path", d.Path)
+	add("git", d.Git)
+	if kind, ref := d.GitRef(); kind != "" {
+		add(kind, ref)
+	}
+	add("realm", d.Realm)
+	if d.Optional {
+		fields = append(fields, "optional = true")
+	}
+	if d.Exclude != nil {
+		globs := make([]string, len(d.Exclude))
+		for i, glob := range d.Exclude {
+			globs[i] = QuoteTOML(glob)
+		}
+		fields = append(fields, "exclude = ["+strings.Join(globs, ", ")+"]")
+	}
+
+	return []byte("{ " + strings.Join(fields, ", ") + " }"), nil
+}
+
+// QuoteTOML writes s as a TOML basic string
+func QuoteTOML(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range s {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\t':
+			b.WriteString(`\t`)
+		case '\r':
+			b.WriteString(`\r`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
@@ -0,0 +1,111 @@
+package breadTypes
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/BurntSushi/toml"
+)
+
+func decodeDependency(t *testing.T, value string) (Dependency, error) {
+	t.Helper()
+	var table struct {
+		Dependencies map[string]Dependency `toml:"dependencies"`
+	}
+	_, err := toml.Decode("[dependencies]\nDep = "+value+"\n", &table)
+	return table.Dependencies["Dep"], err
+}
+
+func TestDependencyUnmarshal(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected Dependency
+		source   string
+	}{
+		{`"sleitnick/signal@^2.0.0"`, Dependency{Spec: "sleitnick/signal@^2.0.0"}, ""},
+		{`{ package = "sleitnick/signal", version = "^2.0.0", realm = "server", optional = true }`,
+			Dependency{Package: "sleitnick/signal", Version: "^2.0.0", Realm: "server", Optional: true}, ""},
+		{`{ package = "me/lib", version = "1.0.0", registry = "https://github.com/me/index" }`,
+			Dependency{Package: "me/lib", Version: "1.0.0", Registry: "https://github.com/me/index"}, "registry+https://github.com/me/index"},
+		{`{ path = "../lib" }`, Dependency{Path: "../lib"}, "path+../lib"},
+		{`{ git = "https://github.com/me/lib.git" }`, Dependency{Git: "https://github.com/me/lib.git"}, "git+https://github.com/me/lib.git"},
+		{`{ git = "https://github.com/me/lib.git", rev = "abc123" }`,
+			Dependency{Git: "https://github.com/me/lib.git", Rev: "abc123"}, "git+https://github.com/me/lib.git?rev=abc123"},
+		{`{ git = "https://github.com/me/lib.git", tag = "v1.2.0" }`,
+			Dependency{Git: "https://github.com/me/lib.git", Tag: "v1.2.0"}, "git+https://github.com/me/lib.git?tag=v1.2.0"},
+		{`{ git = "https://github.com/me/lib.git", branch = "main" }`,
+			Dependency{Git: "https://github.com/me/lib.git", Branch: "main"}, "git+https://github.com/me/lib.git?branch=main"},
+		{`{ package = "me/lib", version = "1.0.0", exclude = ["tests/**", "*.md"] }`,
+			Dependency{Package: "me/lib", Version: "1.0.0", Exclude: []string{"tests/**", "*.md"}}, ""},
+		// an empty list keeps everything, unlike no list at all
+		{`{ git = "https://github.com/me/lib.git", exclude = [] }`,
+			Dependency{Git: "https://github.com/me/lib.git", Exclude: []string{}}, "git+https://github.com/me/lib.git"},
+	}
+
+	for _, tt := range tests {
+		dep, err := decodeDependency(t, tt.value)
+		if err != nil {
+			t.Errorf("Failed to decode %s: %v", tt.value, err)
+			continue
+		}
+		if !reflect.DeepEqual(dep, tt.expected) {
+			t.Errorf("Decoding %s gave %+v, expected %+v", tt.value, dep, tt.expected)
+		}
+		if got := dep.Source(); got != tt.source {
+			t.Errorf("Source of %s = %q, expected %q", tt.value, got, tt.source)
+		}
+	}
+}
+
+func TestDependencyValidate(t *testing.T) {
+	tests := []struct {
+		value, err string
+	}{
+		{`{ path = "../lib", git = "https://github.com/me/lib.git" }`, "both path and git"},
+		{`{ git = "https://github.com/me/lib.git", tag = "v1", branch = "main" }`, "only one of rev, branch or tag"},
+		{`{ version = "^1.0.0" }`, "must have a package, path or git"},
+		{`{ path = "../lib", version = "1.0.0" }`, "only apply to registry dependencies"},
+		{`{ package = "me/lib", tag = "v1" }`, "only apply to git dependencies"},
+		{`{ package = "me/lib", realm = "client" }`, "unknown realm"},
+		{`{ package = "me/lib", optional = "yes" }`, "must be a boolean"},
+		{`{ package = "me/lib", features = "all" }`, "unknown dependency key"},
+		{`{ path = "../lib", exclude = ["tests"] }`, "exclude doesn't apply to path dependencies"},
+		{`{ package = "me/lib", exclude = "tests" }`, "must be an array of strings"},
+		{`{ package = "me/lib", exclude = [1] }`, "must be an array of strings"},
+		{`1`, "unsupported dependency value"},
+	}
+
+	for _, tt := range tests {
+		if _, err := decodeDependency(t, tt.value); err == nil || !strings.Contains(err.Error(), tt.err) {
+			t.Errorf("Decoding %s: expected an error containing %q, got %v", tt.value, tt.err, err)
+		}
+	}
+}
+
+func TestDependencyMarshalRoundTrip(t *testing.T) {
+	deps := []Dependency{
+		{Spec: "sleitnick/signal@^2.0.0"},
+		{Package: "sleitnick/signal", Version: "^2.0.0", Registry: "https://github.com/me/index", Realm: "dev", Optional: true},
+		{Path: `..\libs\"quoted"`},
+		{Git: "https://github.com/me/lib.git", Tag: "v1.2.0"},
+		{Git: "https://github.com/me/lib.git", Rev: "abc123", Realm: "server"},
+		{Package: "me/lib", Version: "1.0.0", Exclude: []string{"tests/**", `"quoted".md`}},
+		{Git: "https://github.com/me/lib.git", Exclude: []string{}},
+	}
+
+	for _, dep := range deps {
+		data, err := dep.MarshalTOML()
+		if err != nil {
+			t.Fatalf("MarshalTOML(%+v) failed: %v", dep, err)
+		}
+		got, err := decodeDependency(t, string(data))
+		if err != nil {
+			t.Errorf("Failed to decode %s: %v", data, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, dep) {
+			t.Errorf("Round trip of %+v gave %+v (%s)", dep, got, data)
+		}
+	}
+}
@@ -3,10 +3,23 @@ package breadTypes
 type Lockfile struct {
 	Registry string          `toml:"registry"`
 	Packages []LockedPackage `toml:"package"`
+	Tools    []LockedTool    `toml:"tool,omitempty"`
 }
 
 type LockedPackage struct {
 	Name         string     `toml:"name"`
 	Version      string     `toml:"version"`
+	Source       string     `toml:"source,omitempty"`
 	Dependencies [][]string `toml:"dependencies"`
+	Overridden   []string   `toml:"overridden,omitempty"` // aliases whose constraint was replaced by [overrides], their edges hold the forced one
+}
+
+// LockedTool pins the release asset of a [tools] entry for one platform
+type LockedTool struct {
+	Name     string `toml:"name"`     // key in [tools]
+	Source   string `toml:"source"`   // GitHub owner/repo
+	Version  string `toml:"version"`  // release tag
+	Platform string `toml:"platform"` // e.g. linux-amd64
+	Asset    string `toml:"asset"`
+	Checksum string `toml:"checksum"` // sha256 of the asset
 }
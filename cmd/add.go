@@ -1,7 +1,6 @@
 package cmd
 
 import (
-	"bytes"
 	"errors"
 	"os"
 	"path/filepath"
@@ -31,11 +30,11 @@ var addCmd = &cobra.Command{
 		depType, _ := cmd.Flags().GetString("types")
 		pkgName, _ := cmd.Flags().GetString("name")
 
-		tomlPath := filepath.Join(projectPath, "bread.toml")
+		tomlPath := utils.ManifestPath(projectPath)
 		var config breadTypes.Config
 
 		if _, err := toml.DecodeFile(tomlPath, &config); err != nil {
-			log.Errorf("Failed to read bread.toml: %s", err)
+			log.Errorf("Failed to read %s: %s", filepath.Base(tomlPath), err)
 			return
 		}
 
@@ -49,21 +48,25 @@ var addCmd = &cobra.Command{
 			}
 		}
 
-		if !addDependency(&config, depType, packageName, packageSpec) {
+		section, dep, ok := addDependency(&config, depType, packageName, packageSpec)
+		if !ok {
 			return
 		}
 
-		// Write back with proper formatting
-		var buf bytes.Buffer
-		encoder := toml.NewEncoder(&buf)
-		encoder.Indent = "  "
-		if err := encoder.Encode(config); err != nil {
-			log.Errorf("Failed to encode bread.toml: %s", err)
+		// Edit in place so comments, order and unknown tables survive
+		editor, err := utils.OpenManifest(tomlPath)
+		if err != nil {
+			log.Errorf("Failed to read %s: %s", filepath.Base(tomlPath), err)
 			return
 		}
 
-		if err := os.WriteFile(tomlPath, buf.Bytes(), 0644); err != nil {
-			log.Errorf("Failed to write bread.toml: %s", err)
+		if err := editor.SetDependency(section, packageName, dep); err != nil {
+			log.Errorf("Failed to update %s: %s", filepath.Base(tomlPath), err)
+			return
+		}
+
+		if err := editor.Save(); err != nil {
+			log.Errorf("Failed to write %s: %s", filepath.Base(tomlPath), err)
 			return
 		}
 
@@ -81,26 +84,27 @@ var addCmd = &cobra.Command{
 	},
 }
 
-func addDependency(config *breadTypes.Config, depType string, packageName, packageSpec string) bool {
-	var deps map[string]string
+// addDependency returns the manifest table and entry for the new dependency
+func addDependency(config *breadTypes.Config, depType string, packageName, packageSpec string) (string, breadTypes.Dependency, bool) {
+	var deps map[string]breadTypes.Dependency
 	var section string
 
 	switch depType {
 	case "server":
 		if config.ServerDependencies == nil {
-			config.ServerDependencies = make(map[string]string)
+			config.ServerDependencies = make(map[string]breadTypes.Dependency)
 		}
 		deps = config.ServerDependencies
-		section = "server_dependencies"
+		section = "server-dependencies"
 	case "dev":
 		if config.DevDependencies == nil {
-			config.DevDependencies = make(map[string]string)
+			config.DevDependencies = make(map[string]breadTypes.Dependency)
 		}
 		deps = config.DevDependencies
-		section = "dev_dependencies"
+		section = "dev-dependencies"
 	default:
 		if config.Dependencies == nil {
-			config.Dependencies = make(map[string]string)
+			config.Dependencies = make(map[string]breadTypes.Dependency)
 		}
 		deps = config.Dependencies
 		section = "dependencies"
@@ -108,11 +112,26 @@ func addDependency(config *breadTypes.Config, depType string, packageName, packa
 
 	if _, exists := deps[packageName]; exists {
 		log.Errorf("Package %s already exists in %s", packageName, section)
-		return false
+		return "", breadTypes.Dependency{}, false
+	}
+
+	dep := newDependency(config, packageName, packageSpec)
+	deps[packageName] = dep
+	return section, dep, true
+}
+
+// newDependency writes the dependency in the table form if the manifest already uses it for registry packages
+func newDependency(config *breadTypes.Config, packageName, packageSpec string) breadTypes.Dependency {
+	for _, deps := range []map[string]breadTypes.Dependency{config.Dependencies, config.ServerDependencies, config.DevDependencies} {
+		for _, dep := range deps {
+			if dep.IsRegistry() && dep.IsTable() {
+				name, constraint := utils.ParsePackageSpec(packageName, packageSpec)
+				return breadTypes.Dependency{Package: name, Version: constraint}
+			}
+		}
 	}
 
-	deps[packageName] = packageSpec
-	return true
+	return breadTypes.Dependency{Spec: packageSpec}
 }
 
 func extractPackageName(spec string) (string, error) {
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Build a .rbxmx model of the installed packages",
+	Long:  "Serialize Packages, ServerPackages and DevPackages, including _Index and the link files, into a Roblox XML model you can drag into Studio without Rojo.",
+	Run: func(cmd *cobra.Command, args []string) {
+		output, _ := cmd.Flags().GetString("output")
+
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			return
+		}
+
+		result, err := installation.WriteBundle(output)
+		if err != nil {
+			log.Errorf("Failed to bundle packages: %s", err)
+			return
+		}
+		log.Infof("%s Wrote %s (%s, %d scripts)", utils.Check, output, strings.Join(result.Folders, ", "), result.Scripts)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(bundleCmd)
+	bundleCmd.Flags().StringP("output", "o", utils.DefaultBundleFile, "Model file to write")
+}
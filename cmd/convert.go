@@ -2,6 +2,7 @@ package cmd
 
 import (
 	"os"
+	"yoheiyayoi/bread/utils"
 
 	"github.com/BurntSushi/toml"
 	"github.com/charmbracelet/log"
@@ -18,7 +19,14 @@ var convertCmd = &cobra.Command{
 	Use:   "convert",
 	Short: "Create wally.toml from bread.toml",
 	Run: func(cmd *cobra.Command, args []string) {
+		lock, _ := cmd.Flags().GetBool("lock")
+
 		if _, err := os.Stat("wally.toml"); err == nil {
+			if lock {
+				// Mid-migration projects keep their wally.toml, only the lockfile is refreshed
+				convertLockfile()
+				return
+			}
 			log.Error("wally.toml already exists!")
 			return
 		}
@@ -46,9 +54,33 @@ var convertCmd = &cobra.Command{
 
 		log.Info("Successfully created wally.toml")
 		log.Warn("Wally doesn't support custom package directories. If you're using a custom directory in Bread, please run wally install or rename the folder.")
+
+		if lock {
+			convertLockfile()
+		}
 	},
 }
 
+func convertLockfile() {
+	projectPath, err := os.Getwd()
+	if err != nil {
+		log.Error("Error getting current directory:", err)
+		return
+	}
+
+	skipped, err := utils.ExportWallyLock(projectPath)
+	if err != nil {
+		log.Error("Failed to create wally.lock:", err)
+		return
+	}
+
+	log.Info("Successfully created wally.lock")
+	for _, pkg := range skipped {
+		log.Warnf("Left out of wally.lock, Wally can't lock it: %s", pkg)
+	}
+}
+
 func init() {
 	rootCmd.AddCommand(convertCmd)
+	convertCmd.Flags().Bool("lock", false, "Also create wally.lock from bread.lock")
 }
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var execCmd = &cobra.Command{
+	Use:   "exec <tool> [args...]",
+	Short: "Run the pinned version of a tool from [tools]",
+	Long:  "Run a tool installed by bread tools install, anything after the tool name is passed on to it.",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			os.Exit(1)
+		}
+
+		binary, err := installation.ToolPath(args[0])
+		if err != nil {
+			log.Errorf("Failed to run tool: %s", err)
+			os.Exit(1)
+		}
+
+		tool := exec.Command(binary, args[1:]...)
+		tool.Stdin = os.Stdin
+		tool.Stdout = os.Stdout
+		tool.Stderr = os.Stderr
+		if err := tool.Run(); err != nil {
+			// pass the tool's exit code on
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.ExitCode())
+			}
+			log.Errorf("Failed to run tool: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(execCmd)
+	// flags after the tool name belong to the tool
+	execCmd.Flags().SetInterspersed(false)
+}
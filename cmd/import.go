@@ -0,0 +1,55 @@
+package cmd
+
+import (
+	"os"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var importCmd = &cobra.Command{
+	Use:   "import",
+	Short: "Create bread.toml and bread.lock from a Wally or pesde project",
+	Long:  "Create bread.toml and bread.lock from wally.toml and wally.lock (or pesde.toml and pesde.lock with --from pesde), keeping every locked version",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		from, _ := cmd.Flags().GetString("from")
+
+		var result *utils.ImportResult
+		switch from {
+		case "wally":
+			result, err = utils.ImportWally(projectPath)
+		case "pesde":
+			result, err = utils.ImportPesde(projectPath)
+		default:
+			log.Errorf("Unknown project type %q, use wally or pesde", from)
+			return
+		}
+		if err != nil {
+			log.Errorf("Failed to import: %s", err)
+			return
+		}
+
+		log.Infof("%s Created bread.toml with %d dependencies", utils.Check, result.Dependencies)
+		if result.Locked > 0 {
+			log.Infof("%s Created bread.lock with %d locked packages", utils.Check, result.Locked)
+		} else {
+			log.Warnf("No %s.lock found, versions will be resolved on the next install", from)
+		}
+
+		for _, skipped := range result.Skipped {
+			log.Warnf("Not converted: %s", skipped)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(importCmd)
+	importCmd.Flags().String("from", "wally", "Project to import (wally, pesde)")
+}
@@ -4,6 +4,7 @@ import (
 	"os"
 	"strings"
 	"yoheiyayoi/bread/breadTypes"
+	"yoheiyayoi/bread/utils"
 
 	"github.com/BurntSushi/toml"
 	"github.com/charmbracelet/log"
@@ -30,7 +31,7 @@ func createProject(name string) {
 		Package: breadTypes.Package{
 			Name:     name,
 			Version:  "0.1.0",
-			Registry: "https://github.com/UpliftGames/wally-index",
+			Registry: utils.DefaultRegistry,
 			Realm:    "shared",
 		},
 
@@ -39,7 +40,7 @@ func createProject(name string) {
 			ServerDir:   "ServerPackages",
 			DevDir:      "DevPackages",
 		},
-		Dependencies: map[string]string{},
+		Dependencies: map[string]breadTypes.Dependency{},
 	}
 
 	encoder := toml.NewEncoder(file)
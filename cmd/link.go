@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"os"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var linkCmd = &cobra.Command{
+	Use:   "link [package]",
+	Short: "Use a local library in place of an installed package",
+	Long:  "Run 'bread link' in a library to register it, then 'bread link <package>' in a project to use the library instead of the installed version. The package can be given by its dependency alias, and stays linked across installs until 'bread unlink'.",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		if len(args) == 0 {
+			name, err := utils.RegisterLink(projectPath)
+			if err != nil {
+				log.Errorf("Failed to register link: %s", err)
+				return
+			}
+			log.Infof("%s Registered %s, run 'bread link %s' in your project to use it", utils.Check, name, name)
+			return
+		}
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			return
+		}
+
+		source, err := installation.LinkPackage(args[0])
+		if err != nil {
+			log.Errorf("Failed to link %s: %s", args[0], err)
+			return
+		}
+		log.Infof("%s Linked %s -> %s", utils.Check, args[0], source)
+	},
+}
+
+var unlinkCmd = &cobra.Command{
+	Use:   "unlink [package]",
+	Short: "Restore the registry version of a linked package",
+	Long:  "Run 'bread unlink <package>' in a project to go back to the registry version, or 'bread unlink' in a library to unregister it",
+	Args:  cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		if len(args) == 0 {
+			name, err := utils.UnregisterLink(projectPath)
+			if err != nil {
+				log.Errorf("Failed to unregister link: %s", err)
+				return
+			}
+			log.Infof("%s Unregistered %s", utils.Check, name)
+			return
+		}
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			return
+		}
+
+		if err := installation.UnlinkPackage(args[0]); err != nil {
+			log.Errorf("Failed to unlink %s: %s", args[0], err)
+			return
+		}
+		log.Infof("%s Restored %s from the registry", utils.Check, args[0])
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(linkCmd)
+	rootCmd.AddCommand(unlinkCmd)
+}
@@ -53,7 +53,7 @@ func loadManifest() (*breadTypes.Config, error) {
 		return nil, fmt.Errorf("error getting current directory: %w", err)
 	}
 
-	tomlPath := filepath.Join(projectPath, "bread.toml")
+	tomlPath := utils.ManifestPath(projectPath)
 	var manifest breadTypes.Config
 	if _, err := toml.DecodeFile(tomlPath, &manifest); err != nil {
 		return nil, fmt.Errorf("failed to parse manifest: %w", err)
@@ -90,15 +90,20 @@ func findOutdatedPackages(manifest *breadTypes.Config, lockfile map[string][]bre
 	checker := utils.NewVersionChecker()
 	outdated := []outdatedPackage{}
 
-	depGroups := map[string]map[string]string{
+	depGroups := map[string]map[string]breadTypes.Dependency{
 		"shared": manifest.Dependencies,
 		"server": manifest.ServerDependencies,
 		"dev":    manifest.DevDependencies,
 	}
 
 	for realm, deps := range depGroups {
-		for name, constraint := range deps {
-			if pkg := checkPackageVersion(name, constraint, realm, lockfile, checker); pkg != nil {
+		for name, dep := range deps {
+			// Path and git packages have no registry version to compare against
+			if !dep.IsRegistry() {
+				continue
+			}
+
+			if pkg := checkPackageVersion(utils.NormalizeDependency(name, dep), realm, lockfile, checker); pkg != nil {
 				outdated = append(outdated, *pkg)
 			}
 		}
@@ -108,8 +113,8 @@ func findOutdatedPackages(manifest *breadTypes.Config, lockfile map[string][]bre
 }
 
 // checkPackageVersion checks if a single package is outdated
-func checkPackageVersion(name, constraint, realm string, lockfile map[string][]breadTypes.LockedPackage, checker *utils.VersionChecker) *outdatedPackage {
-	pkgName, _ := utils.ParsePackageSpec(name, constraint)
+func checkPackageVersion(dep breadTypes.Dependency, realm string, lockfile map[string][]breadTypes.LockedPackage, checker *utils.VersionChecker) *outdatedPackage {
+	pkgName := dep.Package
 
 	currentVersion := checker.GetCurrentVersion(lockfile, pkgName)
 	if currentVersion == "" {
@@ -117,7 +122,7 @@ func checkPackageVersion(name, constraint, realm string, lockfile map[string][]b
 		return nil
 	}
 
-	latestVersion, err := utils.GetPackageVersion(pkgName)
+	latestVersion, err := utils.GetPackageVersion(dep.Registry, pkgName)
 	if err != nil {
 		log.Warn("Failed to resolve latest version", "package", pkgName, "error", err)
 		return nil
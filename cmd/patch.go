@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var patchCmd = &cobra.Command{
+	Use:   "patch <package>[@version]",
+	Short: "Edit an installed package and save the changes as a patch",
+	Long:  "Get an editable copy of an installed package, then save your changes with 'bread patch --commit <package>'. Pass a version when several versions of the package are installed. Patches in patches/ are applied on every install.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		packageName := args[0]
+		commit, _ := cmd.Flags().GetBool("commit")
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			return
+		}
+
+		if !commit {
+			dir, err := installation.PreparePatch(packageName)
+			if err != nil {
+				log.Errorf("Failed to prepare patch: %s", err)
+				return
+			}
+
+			if rel, err := filepath.Rel(projectPath, dir); err == nil {
+				dir = rel
+			}
+			log.Infof("You can now edit %s", dir)
+			log.Infof("Run 'bread patch --commit %s' to save your changes", packageName)
+			return
+		}
+
+		patchPath, err := installation.CommitPatch(packageName)
+		if err != nil {
+			log.Errorf("Failed to save patch: %s", err)
+			return
+		}
+
+		if patchPath == "" {
+			log.Infof("No changes to %s, no patch saved", packageName)
+		} else {
+			if rel, err := filepath.Rel(projectPath, patchPath); err == nil {
+				patchPath = rel
+			}
+			log.Infof("%s Saved %s", utils.Check, patchPath)
+		}
+
+		// Reinstall so the installed package matches the patch
+		if err := installation.Install(); err != nil {
+			log.Error("Installation failed:", err)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(patchCmd)
+	patchCmd.Flags().Bool("commit", false, "Save the edited copy as a patch")
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var relocateCmd = &cobra.Command{
+	Use:   "relocate",
+	Short: "Rename package folders and update the requires that use them",
+	Long:  "Move the shared, server or dev package folder, update [bread] and [place] in bread.toml and rewrite require paths in your .lua/.luau files",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		dirs := make(map[utils.Realm]string)
+		for flag, realm := range map[string]utils.Realm{
+			"shared-dir": utils.RealmShared,
+			"server-dir": utils.RealmServer,
+			"dev-dir":    utils.RealmDev,
+		} {
+			if dir, _ := cmd.Flags().GetString(flag); dir != "" {
+				dirs[realm] = dir
+			}
+		}
+		if len(dirs) == 0 {
+			log.Error("Nothing to relocate, pass --shared-dir, --server-dir or --dev-dir")
+			return
+		}
+
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			return
+		}
+
+		result, err := installation.Relocate(dirs, dryRun)
+		if err != nil {
+			log.Errorf("Failed to relocate: %s", err)
+			return
+		}
+
+		if len(result.Moved) == 0 {
+			log.Info("Package folders are already there")
+			return
+		}
+
+		if dryRun {
+			fmt.Print(result.Diff)
+			for _, move := range result.Moved {
+				log.Infof("Would move %s", move)
+			}
+			log.Infof("Would rewrite %d files", len(result.Files))
+			return
+		}
+
+		for _, move := range result.Moved {
+			log.Infof("%s Moved %s", utils.Check, move)
+		}
+		log.Infof("%s Rewrote requires in %d files", utils.Check, len(result.Files))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(relocateCmd)
+	relocateCmd.Flags().String("shared-dir", "", "New folder for shared packages")
+	relocateCmd.Flags().String("server-dir", "", "New folder for server packages")
+	relocateCmd.Flags().String("dev-dir", "", "New folder for dev packages")
+	relocateCmd.Flags().Bool("dry-run", false, "Show the changes without making them")
+}
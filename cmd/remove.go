@@ -1,14 +1,11 @@
 package cmd
 
 import (
-	"bytes"
 	"os"
 	"path/filepath"
 
-	"yoheiyayoi/bread/breadTypes"
 	"yoheiyayoi/bread/utils"
 
-	"github.com/BurntSushi/toml"
 	"github.com/charmbracelet/log"
 	"github.com/spf13/cobra"
 )
@@ -27,30 +24,22 @@ var removeCmd = &cobra.Command{
 		}
 
 		packageName := args[0]
-		tomlPath := filepath.Join(projectPath, "bread.toml")
-		var config breadTypes.Config
+		tomlPath := utils.ManifestPath(projectPath)
 
-		if _, err := toml.DecodeFile(tomlPath, &config); err != nil {
-			log.Error("Failed to read bread.toml:", err)
+		// Edit in place so comments, order and unknown tables survive
+		editor, err := utils.OpenManifest(tomlPath)
+		if err != nil {
+			log.Errorf("Failed to read %s: %s", filepath.Base(tomlPath), err)
 			return
 		}
 
-		if !removeDependency(&config, packageName) {
+		if !removeDependency(editor, packageName) {
 			log.Errorf("Package %s not found in dependencies", packageName)
 			return
 		}
 
-		// Write back with proper formatting
-		var buf bytes.Buffer
-		encoder := toml.NewEncoder(&buf)
-		encoder.Indent = "  "
-		if err := encoder.Encode(config); err != nil {
-			log.Error("Failed to encode bread.toml:", err)
-			return
-		}
-
-		if err := os.WriteFile(tomlPath, buf.Bytes(), 0644); err != nil {
-			log.Error("Failed to write bread.toml:", err)
+		if err := editor.Save(); err != nil {
+			log.Errorf("Failed to write %s: %s", filepath.Base(tomlPath), err)
 			return
 		}
 
@@ -75,30 +64,13 @@ var removeCmd = &cobra.Command{
 	},
 }
 
-func removeDependency(config *breadTypes.Config, packageName string) bool {
+func removeDependency(editor *utils.ManifestEditor, packageName string) bool {
 	found := false
-
-	if config.Dependencies != nil {
-		if _, exists := config.Dependencies[packageName]; exists {
-			delete(config.Dependencies, packageName)
-			found = true
-		}
-	}
-
-	if config.ServerDependencies != nil {
-		if _, exists := config.ServerDependencies[packageName]; exists {
-			delete(config.ServerDependencies, packageName)
+	for _, section := range []string{"dependencies", "server-dependencies", "dev-dependencies"} {
+		if editor.RemoveDependency(section, packageName) {
 			found = true
 		}
 	}
-
-	if config.DevDependencies != nil {
-		if _, exists := config.DevDependencies[packageName]; exists {
-			delete(config.DevDependencies, packageName)
-			found = true
-		}
-	}
-
 	return found
 }
 
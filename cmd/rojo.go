@@ -0,0 +1,50 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var rojoCmd = &cobra.Command{
+	Use:   "rojo",
+	Short: "Work with the Rojo project file",
+}
+
+var rojoSyncCmd = &cobra.Command{
+	Use:   "sync",
+	Short: "Mount the package folders in the Rojo project file",
+	Long:  "Insert or update the $path of Packages, ServerPackages and DevPackages in the Rojo project file ([bread] rojo_project, default.project.json by default). Set rojo_project to do this on every install.",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			return
+		}
+
+		path, changed, err := installation.SyncRojoProject()
+		if err != nil {
+			log.Errorf("Failed to sync Rojo project: %s", err)
+			return
+		}
+
+		if changed {
+			log.Infof("%s Updated %s", utils.Check, filepath.Base(path))
+		} else {
+			log.Infof("%s is up to date", filepath.Base(path))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(rojoCmd)
+	rojoCmd.AddCommand(rojoSyncCmd)
+}
@@ -0,0 +1,61 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"slices"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var runCmd = &cobra.Command{
+	Use:   "run <script> [args...]",
+	Short: "Run a script from [scripts] in bread.toml",
+	Long:  "Run a command from [scripts] in the project directory, anything after the script name is passed on to it. Without a name the scripts are listed. preinstall and postinstall run around bread install.",
+	Args:  cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			return
+		}
+
+		if len(args) == 0 {
+			scripts := installation.Manifest.Scripts
+			if len(scripts) == 0 {
+				log.Info("No scripts in bread.toml, add them under [scripts]")
+				return
+			}
+			for _, name := range slices.Sorted(maps.Keys(scripts)) {
+				fmt.Printf("%s\n  %s\n", name, scripts[name])
+			}
+			return
+		}
+
+		if err := installation.RunScript(args[0], args[1:]); err != nil {
+			// pass the script's exit code on, so CI sees the failure
+			var exitErr *exec.ExitError
+			if errors.As(err, &exitErr) {
+				os.Exit(exitErr.ExitCode())
+			}
+			log.Errorf("Failed to run script: %s", err)
+			os.Exit(1)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(runCmd)
+	// flags after the script name belong to the script
+	runCmd.Flags().SetInterspersed(false)
+}
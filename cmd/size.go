@@ -0,0 +1,68 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var sizeCmd = &cobra.Command{
+	Use:   "size",
+	Short: "Show how much space installed packages take",
+	Long:  "List the installed packages by size on disk, with what install_exclude in [bread] or the exclude of a dependency kept out of each. Packages marked * were extracted with other globs, 'bread install' extracts them again.",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			return
+		}
+
+		sizes, err := installation.PackageSizes()
+		if err != nil {
+			log.Errorf("Failed to measure packages: %s", err)
+			return
+		}
+		if len(sizes) == 0 {
+			log.Info("No packages installed")
+			return
+		}
+
+		var installed, skipped int64
+		skippedFiles, stale := 0, 0
+		fmt.Println()
+		for _, size := range sizes {
+			line := fmt.Sprintf("  %-40s %-7s %10s", size.Name, size.Realm, utils.FormatSize(size.Installed))
+			if size.SkippedFiles > 0 {
+				line += color.HiBlackString("  (%s in %d files skipped)", utils.FormatSize(size.Skipped), size.SkippedFiles)
+			}
+			if size.Stale {
+				line += color.YellowString("  *")
+				stale++
+			}
+			fmt.Println(line)
+
+			installed += size.Installed
+			skipped += size.Skipped
+			skippedFiles += size.SkippedFiles
+		}
+		fmt.Println()
+
+		log.Infof("%d packages, %s installed, %s in %d files skipped by install_exclude", len(sizes), utils.FormatSize(installed), utils.FormatSize(skipped), skippedFiles)
+		if stale > 0 {
+			log.Warnf("install_exclude changed since %d packages (*) were installed, run 'bread install' to extract them again", stale)
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sizeCmd)
+}
@@ -0,0 +1,39 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var sourcemapCmd = &cobra.Command{
+	Use:   "sourcemap",
+	Short: "Write sourcemap.json for luau-lsp",
+	Long:  "Add the package folders, _Index packages and link files to sourcemap.json so luau-lsp can resolve requires without rojo sourcemap. Set sourcemap = true in [bread] to do this on every install.",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			return
+		}
+
+		path, err := installation.WriteSourcemap()
+		if err != nil {
+			log.Errorf("Failed to write sourcemap: %s", err)
+			return
+		}
+		log.Infof("%s Wrote %s", utils.Check, filepath.Base(path))
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sourcemapCmd)
+}
@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/spf13/cobra"
+)
+
+var toolsCmd = &cobra.Command{
+	Use:   "tools",
+	Short: "Manage the CLI tools pinned in [tools]",
+}
+
+var toolsInstallCmd = &cobra.Command{
+	Use:   "install",
+	Short: "Download the tools in [tools] and pin them in bread.lock",
+	Long:  "Download the GitHub release asset of every [tools] entry (rojo = \"rojo-rbx/rojo@7.4.1\") for this platform into ~/.bread/tools. The asset and its checksum are pinned in bread.lock, a pinned asset that changes on the server fails to install. Set tools_url in [bread] to use another GitHub API.",
+	Run: func(cmd *cobra.Command, args []string) {
+		projectPath, err := os.Getwd()
+		if err != nil {
+			log.Error("Error getting current directory:", err)
+			return
+		}
+
+		installation := utils.NewInstaller(projectPath, nil, nil)
+		if installation == nil {
+			return
+		}
+
+		if len(installation.Manifest.Tools) == 0 {
+			log.Info("No tools in bread.toml, add them under [tools]")
+			return
+		}
+
+		result, err := installation.InstallTools()
+		if err != nil {
+			log.Errorf("Failed to install tools: %s", err)
+			return
+		}
+
+		if len(result.Installed) > 0 {
+			log.Infof("%s Installed %s", utils.Check, strings.Join(result.Installed, ", "))
+		}
+		if len(result.UpToDate) > 0 {
+			log.Infof("%s Up to date: %s", utils.Info, strings.Join(result.UpToDate, ", "))
+		}
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(toolsCmd)
+	toolsCmd.AddCommand(toolsInstallCmd)
+}
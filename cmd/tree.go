@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"yoheiyayoi/bread/breadTypes"
+	"yoheiyayoi/bread/utils"
+
+	"github.com/charmbracelet/log"
+	"github.com/fatih/color"
+	"github.com/spf13/cobra"
+)
+
+var treeCmd = &cobra.Command{
+	Use:   "tree",
+	Short: "Show the locked dependency tree",
+	Run: func(cmd *cobra.Command, args []string) {
+		lockfile, err := loadLockfile()
+		if err != nil {
+			log.Error("Failed to read lockfile", "error", err)
+			return
+		}
+
+		printDependencyTree(os.Stdout, lockfile)
+	},
+}
+
+// lockedEdge is a dependency of a locked package resolved to the locked package it points at
+type lockedEdge struct {
+	Alias      string
+	Package    *breadTypes.LockedPackage
+	Overridden bool
+}
+
+func printDependencyTree(w io.Writer, lockfile map[string][]breadTypes.LockedPackage) {
+	edges := make(map[*breadTypes.LockedPackage][]lockedEdge)
+	dependedOn := make(map[*breadTypes.LockedPackage]bool)
+
+	var packages []*breadTypes.LockedPackage
+	for name := range lockfile {
+		for i := range lockfile[name] {
+			packages = append(packages, &lockfile[name][i])
+		}
+	}
+	sort.Slice(packages, func(i, j int) bool {
+		return packageLabel(packages[i]) < packageLabel(packages[j])
+	})
+
+	for _, pkg := range packages {
+		for _, dep := range pkg.Dependencies {
+			if len(dep) < 2 {
+				continue
+			}
+
+			if target := findLockedPackage(lockfile, dep[0], dep[1]); target != nil {
+				overridden := slices.Contains(pkg.Overridden, dep[0])
+				edges[pkg] = append(edges[pkg], lockedEdge{Alias: dep[0], Package: target, Overridden: overridden})
+				dependedOn[target] = true
+			}
+		}
+	}
+
+	// Roots are the project and workspace members, nothing depends on them
+	for _, pkg := range packages {
+		if !dependedOn[pkg] {
+			fmt.Fprintln(w, color.New(color.Bold).Sprint(packageLabel(pkg)))
+			printTreeEdges(w, edges, pkg, "", map[*breadTypes.LockedPackage]bool{pkg: true})
+		}
+	}
+}
+
+func printTreeEdges(w io.Writer, edges map[*breadTypes.LockedPackage][]lockedEdge, pkg *breadTypes.LockedPackage, prefix string, seen map[*breadTypes.LockedPackage]bool) {
+	for i, edge := range edges[pkg] {
+		branch, indent := "├── ", "│   "
+		if i == len(edges[pkg])-1 {
+			branch, indent = "└── ", "    "
+		}
+
+		line := fmt.Sprintf("%s%s%s %s", prefix, branch, edge.Alias, packageLabel(edge.Package))
+		if edge.Overridden {
+			line += color.YellowString(" (overridden)")
+		}
+
+		if seen[edge.Package] {
+			fmt.Fprintln(w, line+color.HiBlackString(" (cycle)"))
+			continue
+		}
+		fmt.Fprintln(w, line)
+
+		seen[edge.Package] = true
+		printTreeEdges(w, edges, edge.Package, prefix+indent, seen)
+		delete(seen, edge.Package)
+	}
+}
+
+func packageLabel(pkg *breadTypes.LockedPackage) string {
+	label := fmt.Sprintf("%s@%s", pkg.Name, pkg.Version)
+	if pkg.Source != "" {
+		label += color.HiBlackString(" (%s)", pkg.Source)
+	}
+	return label
+}
+
+// findLockedPackage finds what a lockfile dependency entry was resolved to.
+// Overridden edges hold the forced version, so they match like any other.
+func findLockedPackage(lockfile map[string][]breadTypes.LockedPackage, alias, spec string) *breadTypes.LockedPackage {
+	if strings.HasPrefix(spec, "path+") || strings.HasPrefix(spec, "git+") {
+		for name := range lockfile {
+			for i, pkg := range lockfile[name] {
+				if pkg.Source == spec || strings.HasPrefix(pkg.Source, spec+"#") {
+					return &lockfile[name][i]
+				}
+			}
+		}
+		return nil
+	}
+
+	name, constraint := utils.ParsePackageSpec(alias, spec)
+	candidates := lockfile[name]
+	for i, pkg := range candidates {
+		if utils.MatchConstraint(pkg.Version, constraint) {
+			return &candidates[i]
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(treeCmd)
+}
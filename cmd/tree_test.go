@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+
+	"github.com/fatih/color"
+)
+
+func TestPrintDependencyTree(t *testing.T) {
+	noColor := color.NoColor
+	color.NoColor = true
+	defer func() { color.NoColor = noColor }()
+
+	lockfile := map[string][]breadTypes.LockedPackage{
+		"me/game": {{
+			Name:    "me/game",
+			Version: "0.1.0",
+			Dependencies: [][]string{
+				{"Lib", "path+../lib"},
+				{"Promise", "evaera/promise@4.0.2"},
+				{"Signal", "sleitnick/signal@^1.0.0"},
+			},
+			Overridden: []string{"Promise"},
+		}},
+		"me/lib": {{Name: "me/lib", Version: "1.0.0", Source: "path+../lib", Dependencies: [][]string{{"Promise", "evaera/promise@^4.1.0"}}}},
+		// the newer version is listed first, the override still points at 4.0.2
+		"evaera/promise": {
+			{Name: "evaera/promise", Version: "4.1.0"},
+			{Name: "evaera/promise", Version: "4.0.2"},
+		},
+		"sleitnick/signal": {{Name: "sleitnick/signal", Version: "1.2.0"}},
+	}
+
+	var out strings.Builder
+	printDependencyTree(&out, lockfile)
+
+	expected := `me/game@0.1.0
+├── Lib me/lib@1.0.0 (path+../lib)
+│   └── Promise evaera/promise@4.1.0
+├── Promise evaera/promise@4.0.2 (overridden)
+└── Signal sleitnick/signal@1.2.0
+`
+	if out.String() != expected {
+		t.Errorf("Unexpected tree.\nGot:\n%s\nExpected:\n%s", out.String(), expected)
+	}
+}
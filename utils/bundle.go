@@ -0,0 +1,118 @@
+package utils
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultBundleFile is where bread bundle writes the model
+const DefaultBundleFile = "Packages.rbxmx"
+
+// BundleResult says what went into the model
+type BundleResult struct {
+	Folders []string // top level folders, e.g. Packages, ServerPackages
+	Scripts int
+}
+
+// WriteBundle serializes the package folders into a Roblox XML model (.rbxmx) that can be
+// dragged into Studio. Folders and scripts map the same way they do in the sourcemap.
+func (ic *InstallationContext) WriteBundle(output string) (*BundleResult, error) {
+	result := &BundleResult{}
+
+	var roots []*SourcemapNode
+	for _, mount := range ic.rojoMounts() {
+		name := mount.instance[len(mount.instance)-1]
+		if node := ic.sourcemapPath(name, mount.dir, 0); node != nil {
+			roots = append(roots, node)
+			result.Folders = append(result.Folders, name)
+		}
+	}
+	if len(roots) == 0 {
+		return nil, fmt.Errorf("no packages installed, run bread install first")
+	}
+
+	file, err := os.Create(output)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	w := &rbxmxWriter{w: bufio.NewWriter(file), projectPath: ic.ProjectPath}
+	w.WriteString(`<roblox xmlns:xmime="http://www.w3.org/2005/05/xmlmime" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:noNamespaceSchemaLocation="http://www.roblox.com/roblox.xsd" version="4">` + "\n")
+	for _, root := range roots {
+		if err := w.item(root, 1); err != nil {
+			return nil, err
+		}
+	}
+	w.WriteString("</roblox>\n")
+
+	result.Scripts = w.scripts
+	if err := w.w.Flush(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+type rbxmxWriter struct {
+	w           *bufio.Writer
+	projectPath string
+	referent    int
+	scripts     int
+}
+
+func (w *rbxmxWriter) WriteString(s string) {
+	w.w.WriteString(s)
+}
+
+// item writes one instance and its children
+func (w *rbxmxWriter) item(node *SourcemapNode, depth int) error {
+	indent := strings.Repeat("\t", depth)
+	w.referent++
+	fmt.Fprintf(w.w, "%s<Item class=\"%s\" referent=\"RBX%d\">\n", indent, xmlEscape(node.ClassName), w.referent)
+	w.WriteString(indent + "\t<Properties>\n")
+	fmt.Fprintf(w.w, "%s\t\t<string name=\"Name\">%s</string>\n", indent, xmlEscape(node.Name))
+
+	if source := scriptSource(node.FilePaths); source != "" {
+		data, err := os.ReadFile(filepath.Join(w.projectPath, filepath.FromSlash(source)))
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(w.w, "%s\t\t<ProtectedString name=\"Source\">%s</ProtectedString>\n", indent, cdata(string(data)))
+		w.scripts++
+	}
+	w.WriteString(indent + "\t</Properties>\n")
+
+	for _, child := range node.Children {
+		if err := w.item(child, depth+1); err != nil {
+			return err
+		}
+	}
+
+	w.WriteString(indent + "</Item>\n")
+	return nil
+}
+
+// scriptSource picks the .lua/.luau file of a node, a node can also list the project file it came from
+func scriptSource(filePaths []string) string {
+	for _, path := range filePaths {
+		if ext := filepath.Ext(path); ext == ".lua" || ext == ".luau" {
+			return path
+		}
+	}
+	return ""
+}
+
+func xmlEscape(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// cdata wraps source in CDATA, splitting any ]]> it contains across two sections
+func cdata(s string) string {
+	return "<![CDATA[" + strings.ReplaceAll(s, "]]>", "]]]]><![CDATA[>") + "]]>"
+}
@@ -0,0 +1,102 @@
+package utils
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteBundle(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bread.toml":          "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n",
+		"Packages/Signal.lua": "return require(script.Parent._Index[\"sleitnick_signal@2.0.0\"][\"signal\"])\n",
+		"Packages/_Index/sleitnick_signal@2.0.0/signal/default.project.json": `{"name": "signal", "tree": {"$path": "src"}}`,
+		"Packages/_Index/sleitnick_signal@2.0.0/signal/src/init.luau":        "local s = [[a]]..\"]]>\"\nreturn {}\n",
+		"Packages/_Index/sleitnick_signal@2.0.0/signal/src/Util.lua":         "return 1 < 2\n",
+		"Packages/_Index/sleitnick_signal@2.0.0/signal/wally.toml":           "",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+
+	output := filepath.Join(dir, DefaultBundleFile)
+	result, err := ic.WriteBundle(output)
+	if err != nil {
+		t.Fatalf("WriteBundle failed: %v", err)
+	}
+	if result.Scripts != 3 || len(result.Folders) != 1 || result.Folders[0] != "Packages" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	type item struct {
+		Class      string `xml:"class,attr"`
+		Properties struct {
+			Strings []struct {
+				Name  string `xml:"name,attr"`
+				Value string `xml:",chardata"`
+			} `xml:"string"`
+			Source *string `xml:"ProtectedString"`
+		} `xml:"Properties"`
+		Items []item `xml:"Item"`
+	}
+	var model struct {
+		Items []item `xml:"Item"`
+	}
+
+	data, _ := os.ReadFile(output)
+	if err := xml.Unmarshal(data, &model); err != nil {
+		t.Fatalf("Invalid model: %v\n%s", err, data)
+	}
+
+	name := func(it item) string {
+		for _, s := range it.Properties.Strings {
+			if s.Name == "Name" {
+				return s.Value
+			}
+		}
+		return ""
+	}
+	find := func(items []item, n string) *item {
+		for i := range items {
+			if name(items[i]) == n {
+				return &items[i]
+			}
+		}
+		return nil
+	}
+
+	if len(model.Items) != 1 || name(model.Items[0]) != "Packages" || model.Items[0].Class != "Folder" {
+		t.Fatalf("Expected a Packages folder:\n%s", data)
+	}
+	packages := model.Items[0]
+
+	if link := find(packages.Items, "Signal"); link == nil || link.Class != "ModuleScript" || link.Properties.Source == nil {
+		t.Errorf("Expected the Signal link file:\n%s", data)
+	}
+
+	index := find(packages.Items, "_Index")
+	if index == nil {
+		t.Fatalf("Expected _Index:\n%s", data)
+	}
+	version := find(index.Items, "sleitnick_signal@2.0.0")
+	if version == nil {
+		t.Fatalf("Expected the package folder:\n%s", data)
+	}
+
+	// init.luau becomes the package script, with its source intact
+	signal := find(version.Items, "signal")
+	if signal == nil || signal.Class != "ModuleScript" || signal.Properties.Source == nil {
+		t.Fatalf("Expected signal as a ModuleScript:\n%s", data)
+	}
+	if *signal.Properties.Source != "local s = [[a]]..\"]]>\"\nreturn {}\n" {
+		t.Errorf("Source didn't survive: %q", *signal.Properties.Source)
+	}
+	if util := find(signal.Items, "Util"); util == nil || !strings.Contains(*util.Properties.Source, "1 < 2") {
+		t.Errorf("Expected Util under signal:\n%s", data)
+	}
+}
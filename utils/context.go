@@ -4,10 +4,10 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"sync"
 	"time"
 	"yoheiyayoi/bread/breadTypes"
 
-	"github.com/BurntSushi/toml"
 	"github.com/charmbracelet/log"
 	"github.com/fatih/color"
 )
@@ -23,6 +23,13 @@ type InstallationContext struct {
 	SharedPath  *string
 	ServerPath  *string
 	Client      *http.Client
+
+	// WorkspaceRoot is the directory of the shared bread.lock when this is a workspace member
+	WorkspaceRoot string
+
+	workspace   map[string]*workspaceMember // package name -> member
+	overrides   map[string]string           // [overrides] of the root manifest
+	gitPackages sync.Map                    // dependency source -> *gitPackage resolved this run
 }
 
 type Realm string
@@ -44,40 +51,70 @@ func init() {
 }
 
 func NewInstaller(projectPath string, sharedPath *string, serverPath *string) *InstallationContext {
-	configPath := filepath.Join(projectPath, "bread.toml")
-	var config breadTypes.Config
-	if _, err := toml.DecodeFile(configPath, &config); err != nil {
+	// Wally projects work as is, wally.toml is read when there is no bread.toml
+	manifest, err := readPackageManifest(projectPath)
+	if err != nil {
+		log.Errorf("Failed to read manifest: %s", err)
+		return nil
+	}
+	if manifest == nil {
 		log.Error("bread.toml not found!, Run bread init first")
 		return nil
 	}
+	config := *manifest
 
-	// Load lockfile
-	lockPath := filepath.Join(projectPath, "bread.lock")
-	var lockfileData breadTypes.Lockfile
-	lockfileMap := make(map[string][]breadTypes.LockedPackage)
+	switch config.BreadConfig.Target {
+	case "", TargetRoblox, TargetLuau, TargetLune:
+	default:
+		log.Errorf("Unknown target %q, use roblox, luau or lune", config.BreadConfig.Target)
+		return nil
+	}
+
+	// [place] says where the realm folders live in the game
+	if config.Place != nil {
+		if sharedPath == nil && config.Place.SharedPackages != "" {
+			sharedPath = &config.Place.SharedPackages
+		}
+		if serverPath == nil && config.Place.ServerPackages != "" {
+			serverPath = &config.Place.ServerPackages
+		}
+	}
 
-	if _, err := toml.DecodeFile(lockPath, &lockfileData); err == nil {
+	// Load lockfile, wally.lock keeps the versions of a Wally project until bread.lock is written
+	lockfileMap := make(map[string][]breadTypes.LockedPackage)
+	lockfileData, err := readLockfile(filepath.Join(projectPath, "bread.lock"))
+	if err == nil && lockfileData == nil {
+		lockfileData, err = readLockfile(filepath.Join(projectPath, "wally.lock"))
+	}
+	if err != nil {
+		log.Warnf("Ignoring lockfile: %s", err)
+	} else if lockfileData != nil {
 		for _, pkg := range lockfileData.Packages {
 			lockfileMap[pkg.Name] = append(lockfileMap[pkg.Name], pkg)
 		}
 	}
 
-	getDir := func(configDir, defaultName string) string {
-		if configDir != "" {
-			return filepath.Join(projectPath, configDir)
+	var workspace map[string]*workspaceMember
+	if config.Workspace != nil {
+		members, err := loadWorkspace(projectPath, config.Workspace.Members)
+		if err != nil {
+			log.Errorf("Failed to load workspace: %s", err)
+			return nil
 		}
-		return filepath.Join(projectPath, defaultName)
+		workspace = members
 	}
 
 	return &InstallationContext{
 		Manifest:    config,
 		Lockfile:    lockfileMap,
 		ProjectPath: projectPath,
-		SharedDir:   getDir(config.BreadConfig.PackagesDir, "Packages"),
-		ServerDir:   getDir(config.BreadConfig.ServerDir, "ServerPackages"),
-		DevDir:      getDir(config.BreadConfig.DevDir, "DevPackages"),
+		SharedDir:   realmDir(projectPath, config.BreadConfig.PackagesDir, "Packages"),
+		ServerDir:   realmDir(projectPath, config.BreadConfig.ServerDir, "ServerPackages"),
+		DevDir:      realmDir(projectPath, config.BreadConfig.DevDir, "DevPackages"),
 		SharedPath:  sharedPath,
 		ServerPath:  serverPath,
+		workspace:   workspace,
+		overrides:   config.Overrides,
 
 		Client: &http.Client{
 			Transport: &http.Transport{
@@ -90,15 +127,32 @@ func NewInstaller(projectPath string, sharedPath *string, serverPath *string) *I
 	}
 }
 
+func realmDir(projectPath, configDir, defaultName string) string {
+	if configDir != "" {
+		return filepath.Join(projectPath, configDir)
+	}
+	return filepath.Join(projectPath, defaultName)
+}
+
 func (ic *InstallationContext) Clean() error {
-	for _, dir := range []string{ic.SharedDir, ic.ServerDir, ic.DevDir} {
-		if err := os.RemoveAll(dir); err != nil && !os.IsNotExist(err) {
-			return err
+	for _, target := range ic.installTargets() {
+		for _, dir := range []string{target.SharedDir, target.ServerDir, target.DevDir} {
+			if err := os.RemoveAll(dir); err != nil && !os.IsNotExist(err) {
+				return err
+			}
 		}
 	}
 	return nil
 }
 
+// lockRoot is the directory bread.lock is written to
+func (ic *InstallationContext) lockRoot() string {
+	if ic.WorkspaceRoot != "" {
+		return ic.WorkspaceRoot
+	}
+	return ic.ProjectPath
+}
+
 func (ic *InstallationContext) getRealmDir(realm Realm) string {
 	switch realm {
 	case RealmServer:
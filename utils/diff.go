@@ -0,0 +1,455 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	diffContext = 3
+	devNull     = "/dev/null"
+	noNewline   = "\\ No newline at end of file"
+)
+
+type diffOp struct {
+	kind byte // ' ', '-' or '+'
+	line string
+}
+
+// splitLines splits keeping the "\n" so a missing newline at the end is a difference too
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+// diffLines returns the shortest edit script from a to b (Myers' algorithm).
+// Only the diagonals [-d, d] reached in each step are kept for the backtrack,
+// so memory grows with the square of the edit distance instead of the file size
+func diffLines(a, b []string) []diffOp {
+	n, m := len(a), len(b)
+	offset := n + m + 1
+	v := make([]int, 2*offset+1)
+	var trace [][]int
+
+search:
+	for d := 0; d <= n+m; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+			if x >= n && y >= m {
+				trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+				break search
+			}
+		}
+		trace = append(trace, slices.Clone(v[offset-d:offset+d+1]))
+	}
+
+	var ops []diffOp
+	x, y := n, m
+	for d := len(trace) - 1; d >= 0; d-- {
+		k := x - y
+
+		// the furthest x on diagonal k in the step before d
+		prevX, prevY := 0, 0
+		if d > 0 {
+			prev := trace[d-1]
+			at := func(k int) int { return prev[k+d-1] }
+
+			prevK := k - 1
+			if k == -d || (k != d && at(k-1) < at(k+1)) {
+				prevK = k + 1
+			}
+			prevX = at(prevK)
+			prevY = prevX - prevK
+		}
+
+		for x > prevX && y > prevY {
+			ops = append(ops, diffOp{' ', a[x-1]})
+			x--
+			y--
+		}
+		if d == 0 {
+			break
+		}
+		if x == prevX {
+			ops = append(ops, diffOp{'+', b[y-1]})
+			y--
+		} else {
+			ops = append(ops, diffOp{'-', a[x-1]})
+			x--
+		}
+	}
+
+	slices.Reverse(ops)
+	return ops
+}
+
+// unifiedDiff writes the hunks turning a into b, with diff -u style headers
+func unifiedDiff(oldName, newName, a, b string) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	var changes []int
+	for i, op := range ops {
+		if op.kind != ' ' {
+			changes = append(changes, i)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+
+	for i := 0; i < len(changes); {
+		// Grow the hunk while the next change is close enough to share context
+		j := i
+		for j+1 < len(changes) && changes[j+1]-changes[j] <= 2*diffContext {
+			j++
+		}
+		start := max(0, changes[i]-diffContext)
+		end := min(len(ops), changes[j]+diffContext+1)
+
+		oldStart, newStart := 0, 0
+		for _, op := range ops[:start] {
+			if op.kind != '+' {
+				oldStart++
+			}
+			if op.kind != '-' {
+				newStart++
+			}
+		}
+		oldLen, newLen := 0, 0
+		for _, op := range ops[start:end] {
+			if op.kind != '+' {
+				oldLen++
+			}
+			if op.kind != '-' {
+				newLen++
+			}
+		}
+		if oldLen > 0 {
+			oldStart++
+		}
+		if newLen > 0 {
+			newStart++
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+		for _, op := range ops[start:end] {
+			out.WriteByte(op.kind)
+			out.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				out.WriteString("\n" + noNewline + "\n")
+			}
+		}
+
+		i = j + 1
+	}
+
+	return out.String()
+}
+
+// diffDirs builds one patch with every text file that differs between the two directories
+func diffDirs(oldDir, newDir string) (string, error) {
+	files := make(map[string]bool)
+	for _, dir := range []string{oldDir, newDir} {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files[filepath.ToSlash(rel)] = true
+			return nil
+		})
+		if err != nil {
+			return "", err
+		}
+	}
+
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var out strings.Builder
+	for _, name := range names {
+		oldContent, oldErr := os.ReadFile(filepath.Join(oldDir, name))
+		newContent, newErr := os.ReadFile(filepath.Join(newDir, name))
+		if bytes.Equal(oldContent, newContent) && (oldErr == nil) == (newErr == nil) {
+			continue
+		}
+		if bytes.IndexByte(oldContent, 0) != -1 || bytes.IndexByte(newContent, 0) != -1 {
+			return "", fmt.Errorf("binary file %s can't be patched", name)
+		}
+
+		oldName, newName := "a/"+name, "b/"+name
+		if oldErr != nil {
+			oldName = devNull
+		}
+		if newErr != nil {
+			newName = devNull
+		}
+		if len(oldContent) == 0 && len(newContent) == 0 {
+			// An empty file has no lines to diff, the headers alone create or delete it
+			fmt.Fprintf(&out, "--- %s\n+++ %s\n", oldName, newName)
+			continue
+		}
+		out.WriteString(unifiedDiff(oldName, newName, string(oldContent), string(newContent)))
+	}
+
+	return out.String(), nil
+}
+
+type patchHunk struct {
+	oldStart int
+	oldLines []string
+	newLines []string
+}
+
+type filePatch struct {
+	oldName string
+	newName string
+	hunks   []patchHunk
+}
+
+// parsePatch reads the files and hunks of a unified diff
+func parsePatch(patch string) ([]filePatch, error) {
+	var files []filePatch
+	lines := strings.Split(patch, "\n")
+
+	for i := 0; i < len(lines); i++ {
+		if !strings.HasPrefix(lines[i], "--- ") || i+1 >= len(lines) || !strings.HasPrefix(lines[i+1], "+++ ") {
+			continue
+		}
+
+		file := filePatch{
+			oldName: strings.TrimSpace(strings.TrimPrefix(lines[i], "--- ")),
+			newName: strings.TrimSpace(strings.TrimPrefix(lines[i+1], "+++ ")),
+		}
+		i += 2
+
+		for i < len(lines) && strings.HasPrefix(lines[i], "@@ ") {
+			hunk, oldLen, newLen, err := parseHunkHeader(lines[i])
+			if err != nil {
+				return nil, err
+			}
+			i++
+
+			// Count lines instead of looking at prefixes, a removed "-- comment" looks like a file header
+			for ; i < len(lines) && (len(hunk.oldLines) < oldLen || len(hunk.newLines) < newLen || lines[i] == noNewline); i++ {
+				line := lines[i]
+				if line == noNewline {
+					hunk.trimLastNewline(lines[i-1][0])
+					continue
+				}
+				if line == "" || !strings.ContainsRune(" -+", rune(line[0])) {
+					return nil, fmt.Errorf("unexpected line in hunk: %q", line)
+				}
+
+				content := line[1:] + "\n"
+				if line[0] != '+' {
+					hunk.oldLines = append(hunk.oldLines, content)
+				}
+				if line[0] != '-' {
+					hunk.newLines = append(hunk.newLines, content)
+				}
+			}
+			if len(hunk.oldLines) != oldLen || len(hunk.newLines) != newLen {
+				return nil, fmt.Errorf("truncated hunk in %s", file.newName)
+			}
+			file.hunks = append(file.hunks, hunk)
+		}
+
+		files = append(files, file)
+		i--
+	}
+
+	if len(files) == 0 && strings.TrimSpace(patch) != "" {
+		return nil, fmt.Errorf("no file changes found in patch")
+	}
+	return files, nil
+}
+
+func parseHunkHeader(header string) (hunk patchHunk, oldLen, newLen int, err error) {
+	// @@ -oldStart,oldLen +newStart,newLen @@
+	fields := strings.Fields(header)
+	if len(fields) < 3 || !strings.HasPrefix(fields[1], "-") || !strings.HasPrefix(fields[2], "+") {
+		return hunk, 0, 0, fmt.Errorf("invalid hunk header %q", header)
+	}
+
+	parseRange := func(r string) (int, int, error) {
+		start, length, found := strings.Cut(r[1:], ",")
+		if !found {
+			length = "1"
+		}
+		s, err := strconv.Atoi(start)
+		if err != nil {
+			return 0, 0, err
+		}
+		l, err := strconv.Atoi(length)
+		return s, l, err
+	}
+
+	oldStart, oldLen, err := parseRange(fields[1])
+	if err != nil {
+		return hunk, 0, 0, fmt.Errorf("invalid hunk header %q", header)
+	}
+	_, newLen, err = parseRange(fields[2])
+	if err != nil {
+		return hunk, 0, 0, fmt.Errorf("invalid hunk header %q", header)
+	}
+
+	return patchHunk{oldStart: oldStart}, oldLen, newLen, nil
+}
+
+// trimLastNewline handles "\ No newline at end of file" after a line of the given kind
+func (h *patchHunk) trimLastNewline(kind byte) {
+	trim := func(lines []string) {
+		if n := len(lines); n > 0 {
+			lines[n-1] = strings.TrimSuffix(lines[n-1], "\n")
+		}
+	}
+	if kind != '+' {
+		trim(h.oldLines)
+	}
+	if kind != '-' {
+		trim(h.newLines)
+	}
+}
+
+// applyPatch applies a unified diff to the files under dir.
+// Every hunk has to match exactly, nearby offsets are allowed like patch(1) does.
+func applyPatch(dir, patch string) error {
+	files, err := parsePatch(patch)
+	if err != nil {
+		return err
+	}
+
+	// dir is a package folder in _Index, the files install_exclude left out are recorded next to it
+	record, err := readExcludedRecord(filepath.Dir(dir))
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		name := file.newName
+		if name == devNull {
+			name = file.oldName
+		}
+		name = strings.TrimPrefix(strings.TrimPrefix(name, "a/"), "b/")
+
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if !strings.HasPrefix(path, filepath.Clean(dir)+string(os.PathSeparator)) {
+			return fmt.Errorf("illegal file path in patch: %s", name)
+		}
+
+		var lines []string
+		if file.oldName != devNull {
+			content, err := os.ReadFile(path)
+			if os.IsNotExist(err) && record != nil && slices.ContainsFunc(record.Files, func(f excludedFile) bool { return f.Path == name }) {
+				return fmt.Errorf("%s was left out by the exclude globs %q, drop the one matching it to patch the file", name, record.Exclude)
+			}
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			lines = splitLines(string(content))
+		} else if _, err := os.Stat(path); err == nil {
+			return fmt.Errorf("%s: file to create already exists", name)
+		}
+
+		result, err := applyHunks(lines, file.hunks)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+
+		if file.newName == devNull {
+			if len(result) > 0 {
+				return fmt.Errorf("%s: file to delete has unexpected content", name)
+			}
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(path, []byte(strings.Join(result, "")), 0644); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func applyHunks(lines []string, hunks []patchHunk) ([]string, error) {
+	var result []string
+	pos := 0 // next line of the original not copied yet
+
+	for i, hunk := range hunks {
+		want := max(hunk.oldStart-1, 0)
+		if len(hunk.oldLines) == 0 {
+			want = hunk.oldStart
+		}
+
+		at := findHunk(lines, hunk.oldLines, want, pos)
+		if at < 0 {
+			return nil, fmt.Errorf("hunk %d (line %d) doesn't match", i+1, hunk.oldStart)
+		}
+
+		result = append(result, lines[pos:at]...)
+		result = append(result, hunk.newLines...)
+		pos = at + len(hunk.oldLines)
+	}
+
+	return append(result, lines[pos:]...), nil
+}
+
+// findHunk looks for old at want first, then at growing distances from it
+func findHunk(lines, old []string, want, from int) int {
+	matches := func(at int) bool {
+		if at < from || at+len(old) > len(lines) {
+			return false
+		}
+		return slices.Equal(lines[at:at+len(old)], old)
+	}
+
+	for delta := 0; delta <= len(lines); delta++ {
+		if matches(want - delta) {
+			return want - delta
+		}
+		if matches(want + delta) {
+			return want + delta
+		}
+	}
+	return -1
+}
@@ -0,0 +1,178 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeTree(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestDiffAndApplyRoundTrip(t *testing.T) {
+	original := map[string]string{
+		"init.lua":      "local Signal = {}\n-- old comment\nfunction Signal.new()\n\treturn {}\nend\n\nreturn Signal\n",
+		"src/Util.lua":  "return 1",
+		"src/Old.lua":   "return \"old\"\n",
+		"src/Gone.lua":  "",
+		"README.md":     "# Signal\n",
+		"src/Keep.luau": strings.Repeat("-- line\n", 20),
+	}
+	edited := map[string]string{
+		"init.lua":      "local Signal = {}\nfunction Signal.new()\n\treturn { connections = {} }\nend\n\nreturn Signal\n",
+		"src/Util.lua":  "return 2\n",
+		"src/New.lua":   "return \"new\"",
+		"src/Empty.lua": "",
+		"README.md":     "# Signal\n",
+		"src/Keep.luau": strings.Repeat("-- line\n", 20),
+	}
+
+	tmpDir := t.TempDir()
+	oldDir := filepath.Join(tmpDir, "old")
+	newDir := filepath.Join(tmpDir, "new")
+	writeTree(t, oldDir, original)
+	writeTree(t, newDir, edited)
+
+	patch, err := diffDirs(oldDir, newDir)
+	if err != nil {
+		t.Fatalf("diffDirs failed: %v", err)
+	}
+
+	for _, want := range []string{"--- a/init.lua", "-- old comment", "+++ /dev/null", "--- /dev/null", noNewline} {
+		if !strings.Contains(patch, want) {
+			t.Errorf("Expected patch to contain %q, got:\n%s", want, patch)
+		}
+	}
+	if strings.Contains(patch, "README.md") || strings.Contains(patch, "Keep.luau") {
+		t.Errorf("Unchanged files should not be in the patch:\n%s", patch)
+	}
+
+	if err := applyPatch(oldDir, patch); err != nil {
+		t.Fatalf("applyPatch failed: %v\n%s", err, patch)
+	}
+
+	for name, want := range edited {
+		got, err := os.ReadFile(filepath.Join(oldDir, name))
+		if err != nil {
+			t.Fatalf("Expected %s after patching: %v", name, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s doesn't match.\nGot:\n%q\nExpected:\n%q", name, got, want)
+		}
+	}
+	for _, name := range []string{"Old.lua", "Gone.lua"} {
+		if _, err := os.Stat(filepath.Join(oldDir, "src", name)); !os.IsNotExist(err) {
+			t.Errorf("Expected src/%s to be deleted", name)
+		}
+	}
+}
+
+func TestApplyPatchWithOffset(t *testing.T) {
+	before := "a\nb\nc\nd\ne\n"
+	after := "a\nb\nC\nd\ne\n"
+	patch := unifiedDiff("a/file.lua", "b/file.lua", before, after)
+
+	// Lines added above the hunk move it, it should still apply
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"file.lua": "x\ny\n" + before})
+
+	if err := applyPatch(dir, patch); err != nil {
+		t.Fatalf("applyPatch failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, "file.lua"))
+	if string(got) != "x\ny\n"+after {
+		t.Errorf("Unexpected result: %q", got)
+	}
+}
+
+func TestApplyPatchFailsOnChangedSource(t *testing.T) {
+	patch := unifiedDiff("a/init.lua", "b/init.lua", "return 1\n", "return 2\n")
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{"init.lua": "return 3\n"})
+
+	if err := applyPatch(dir, patch); err == nil {
+		t.Errorf("Expected patch to fail on changed source")
+	}
+}
+
+func TestApplyPatchToExcludedFile(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "package.zip")
+	writeZip(t, archive, map[string]string{
+		"init.lua":      "return 1\n",
+		"docs/index.md": "# lib\n",
+	})
+	target := filepath.Join(dir, "me_lib@1.0.0")
+	if err := unzipPackage(archive, target, "me/lib", extractOptions{exclude: []string{"docs"}, maxFiles: 10, maxBytes: 1 << 20, maxRatio: 100}); err != nil {
+		t.Fatalf("unzipPackage failed: %v", err)
+	}
+
+	patch := unifiedDiff("a/docs/index.md", "b/docs/index.md", "# lib\n", "# patched\n")
+	if err := applyPatch(filepath.Join(target, "lib"), patch); err == nil || !strings.Contains(err.Error(), "docs/index.md was left out by the exclude globs") {
+		t.Errorf("Expected an error naming the excluded file, got %v", err)
+	}
+}
+
+func TestDiffLinesIsShortest(t *testing.T) {
+	tests := [][2]string{
+		{"", ""},
+		{"a", ""},
+		{"", "a b"},
+		{"a b c a b b a", "c b a b a c"},
+		{"a b c d e f", "a x c d y f z"},
+		{"x x x x", "y y x"},
+	}
+
+	for _, tt := range tests {
+		a, b := strings.Fields(tt[0]), strings.Fields(tt[1])
+		ops := diffLines(a, b)
+
+		var gotA, gotB []string
+		edits := 0
+		for _, op := range ops {
+			if op.kind != '+' {
+				gotA = append(gotA, op.line)
+			}
+			if op.kind != '-' {
+				gotB = append(gotB, op.line)
+			}
+			if op.kind != ' ' {
+				edits++
+			}
+		}
+		if strings.Join(gotA, " ") != tt[0] || strings.Join(gotB, " ") != tt[1] {
+			t.Errorf("Edit script for %q -> %q doesn't rebuild both sides: %v", tt[0], tt[1], ops)
+		}
+
+		// the shortest script keeps the longest common subsequence
+		lcs := make([][]int, len(a)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(b)+1)
+		}
+		for i := len(a) - 1; i >= 0; i-- {
+			for j := len(b) - 1; j >= 0; j-- {
+				if a[i] == b[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		if expected := len(a) + len(b) - 2*lcs[0][0]; edits != expected {
+			t.Errorf("Expected %d edits for %q -> %q, got %d", expected, tt[0], tt[1], edits)
+		}
+	}
+}
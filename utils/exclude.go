@@ -0,0 +1,241 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+// ExcludedRecordFile records the globs a package was extracted with and what they left out,
+// next to the package folder in _Index
+const ExcludedRecordFile = ".bread-excluded"
+
+// these are what bread and Rojo read from a package, they're always extracted
+var protectedPackageFiles = []string{"wally.toml", "bread.toml", DefaultRojoProject}
+
+type excludedFile struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+type excludedRecord struct {
+	Package string         `json:"package"`
+	Exclude []string       `json:"exclude"`
+	Files   []excludedFile `json:"files"`
+}
+
+// installExclude returns the globs for a package, the exclude of its dependency entry replaces install_exclude
+func (ic *InstallationContext) installExclude(packageName string) []string {
+	for _, table := range []map[string]breadTypes.Dependency{
+		ic.Manifest.Dependencies,
+		ic.Manifest.ServerDependencies,
+		ic.Manifest.DevDependencies,
+	} {
+		for alias, dep := range table {
+			if dep.Exclude != nil && ic.dependencyName(alias, dep) == packageName {
+				return dep.Exclude
+			}
+		}
+	}
+	return ic.Manifest.BreadConfig.InstallExclude
+}
+
+// dependencyName returns the package a registry or git dependency installs, empty if it isn't known yet.
+// A git package is named by its manifest, once it was checked out this run or locked.
+func (ic *InstallationContext) dependencyName(alias string, dep breadTypes.Dependency) string {
+	switch {
+	case dep.IsRegistry():
+		return NormalizeDependency(alias, dep).Package
+	case dep.IsGit():
+		if pkg, ok := ic.gitPackages.Load(dep.Source()); ok {
+			return pkg.(*gitPackage).Name
+		}
+		prefix := dep.Source() + "#"
+		for name, versions := range ic.Lockfile {
+			for _, pkg := range versions {
+				if strings.HasPrefix(pkg.Source, prefix) {
+					return name
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// isExcluded reports whether the file at rel (slash separated, from the package root) matches one of the patterns
+func isExcluded(patterns []string, rel string) bool {
+	for _, protected := range protectedPackageFiles {
+		if rel == protected {
+			return false
+		}
+	}
+	for _, pattern := range patterns {
+		if excludeMatch(pattern, rel) {
+			return true
+		}
+	}
+	return false
+}
+
+// excludeMatch matches like .gitignore: a pattern without a slash matches a name at any depth,
+// one with a slash is from the package root, ** spans folders and a matching folder takes everything in it
+func excludeMatch(pattern, rel string) bool {
+	pattern = strings.TrimSuffix(pattern, "/")
+	parts := strings.Split(rel, "/")
+
+	if !strings.Contains(pattern, "/") {
+		for _, part := range parts {
+			if ok, _ := path.Match(pattern, part); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	segments := strings.Split(strings.TrimPrefix(pattern, "/"), "/")
+	for n := 1; n <= len(parts); n++ {
+		if matchSegments(segments, parts[:n]) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSegments(pattern, parts []string) bool {
+	if len(pattern) == 0 {
+		return len(parts) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(parts); i++ {
+			if matchSegments(pattern[1:], parts[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(parts) == 0 {
+		return false
+	}
+	ok, _ := path.Match(pattern[0], parts[0])
+	return ok && matchSegments(pattern[1:], parts[1:])
+}
+
+func writeExcludedRecord(dir string, record excludedRecord) error {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, ExcludedRecordFile), data, 0644)
+}
+
+func readExcludedRecord(dir string) (*excludedRecord, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ExcludedRecordFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var record excludedRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		return nil, fmt.Errorf("failed to read %s of %s: %w", ExcludedRecordFile, filepath.Base(dir), err)
+	}
+	return &record, nil
+}
+
+// PackageSize is what one package in _Index takes on disk and what install_exclude skipped
+type PackageSize struct {
+	Name         string // folder in _Index, e.g. sleitnick_signal@2.0.0
+	Realm        Realm
+	Installed    int64
+	Skipped      int64
+	SkippedFiles int
+	Stale        bool // install_exclude changed since it was extracted, bread install applies it
+}
+
+// PackageSizes measures every installed package, largest first
+func (ic *InstallationContext) PackageSizes() ([]PackageSize, error) {
+	installed := make(map[string]string) // _Index folder -> package name
+	for name, versions := range ic.Lockfile {
+		for _, pkg := range versions {
+			installed[packageIDFileName(name, pkg.Version)] = name
+		}
+	}
+
+	var sizes []PackageSize
+	for _, realm := range []Realm{RealmShared, RealmServer, RealmDev} {
+		entries, err := os.ReadDir(ic.getIndexDir(realm))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+			dir := filepath.Join(ic.getIndexDir(realm), entry.Name())
+			size := PackageSize{Name: entry.Name(), Realm: realm}
+
+			err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+				if err != nil || d.IsDir() || d.Name() == ExcludedRecordFile {
+					return err
+				}
+				info, err := d.Info()
+				if err != nil {
+					return err
+				}
+				size.Installed += info.Size()
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			record, err := readExcludedRecord(dir)
+			if err != nil {
+				return nil, err
+			}
+			if record != nil {
+				for _, f := range record.Files {
+					size.Skipped += f.Size
+				}
+				size.SkippedFiles = len(record.Files)
+				size.Stale = !slices.Equal(record.Exclude, ic.installExclude(record.Package))
+			} else if name, ok := installed[entry.Name()]; ok && len(ic.installExclude(name)) > 0 {
+				// extracted before the globs were set, linked and path packages aren't extracted
+				if info, err := os.Lstat(filepath.Join(dir, getPackageName(name))); err == nil && info.Mode()&os.ModeSymlink == 0 {
+					size.Stale = true
+				}
+			}
+
+			sizes = append(sizes, size)
+		}
+	}
+
+	sort.SliceStable(sizes, func(i, j int) bool {
+		return sizes[i].Installed > sizes[j].Installed
+	})
+	return sizes, nil
+}
+
+// FormatSize prints bytes as B, KB or MB
+func FormatSize(bytes int64) string {
+	switch {
+	case bytes >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(bytes)/(1<<20))
+	case bytes >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(bytes)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", bytes)
+}
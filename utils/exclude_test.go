@@ -0,0 +1,145 @@
+package utils
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+// writeZip creates a zip with the given entries, names ending in / are folders
+func writeZip(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	out, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create zip: %v", err)
+	}
+	defer out.Close()
+
+	zw := zip.NewWriter(out)
+	for _, name := range sortedKeys(files) {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", name, err)
+		}
+		w.Write([]byte(files[name]))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+}
+
+func TestExcludeMatch(t *testing.T) {
+	tests := []struct {
+		pattern, path string
+		expected      bool
+	}{
+		{".github", ".github/workflows/ci.yml", true},
+		{"*.spec.lua", "src/Signal.spec.lua", true},
+		{"*.spec.lua", "src/Signal.lua", false},
+		{"tests/**", "tests/unit/a.lua", true},
+		{"tests/", "tests/a.lua", true},
+		{"tests/**", "src/tests/a.lua", false},
+		{"**/tests", "src/tests/a.lua", true},
+		{"/docs", "docs/index.md", true},
+		{"src/**/*.md", "src/a/b/README.md", true},
+		{"src/**/*.md", "README.md", false},
+	}
+	for _, tt := range tests {
+		if got := excludeMatch(tt.pattern, tt.path); got != tt.expected {
+			t.Errorf("excludeMatch(%q, %q) = %v, expected %v", tt.pattern, tt.path, got, tt.expected)
+		}
+	}
+
+	if isExcluded([]string{"*.toml", "*.json"}, "wally.toml") || isExcluded([]string{"*.json"}, "default.project.json") {
+		t.Errorf("Expected the package manifest and project file to always be extracted")
+	}
+}
+
+func TestUnzipPackageExclude(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "package.zip")
+	writeZip(t, archive, map[string]string{
+		"wally.toml":                "[package]\nname = \"me/lib\"\n",
+		"src/init.lua":              "return {}\n",
+		"src/init.spec.lua":         "-- 20 bytes of tests",
+		".github/workflows/ci.yml":  "on: push\n",
+		"docs/":                     "",
+		"docs/index.md":             "# lib\n",
+		"examples/deep/example.lua": "print(1)\n",
+	})
+
+	ic := &InstallationContext{ProjectPath: dir, SharedDir: filepath.Join(dir, "Packages")}
+	ic.Manifest.BreadConfig.InstallExclude = []string{".github", "*.spec.lua", "/docs", "examples/**"}
+	ic.Manifest.Dependencies = map[string]breadTypes.Dependency{
+		"Keep": {Package: "me/keep", Version: "^1.0.0", Exclude: []string{}},
+		"Tool": {Git: "https://github.com/me/tool.git", Exclude: []string{"docs"}},
+	}
+	ic.Lockfile = map[string][]breadTypes.LockedPackage{
+		"me/tool": {{Name: "me/tool", Version: "0.1.0", Source: "git+https://github.com/me/tool.git#abc123"}},
+	}
+
+	target := filepath.Join(ic.getIndexDir(RealmShared), "me_lib@1.0.0")
+	if err := unzipPackage(archive, target, "me/lib", ic.extractOptions("me/lib")); err != nil {
+		t.Fatalf("unzipPackage failed: %v", err)
+	}
+
+	for _, kept := range []string{"wally.toml", "src/init.lua"} {
+		if _, err := os.Stat(filepath.Join(target, "lib", kept)); err != nil {
+			t.Errorf("Expected %s to be extracted", kept)
+		}
+	}
+	for _, skipped := range []string{"src/init.spec.lua", ".github", "docs", "examples"} {
+		if _, err := os.Stat(filepath.Join(target, "lib", skipped)); err == nil {
+			t.Errorf("Expected %s to be excluded", skipped)
+		}
+	}
+
+	// a git dependency is found by the name it was locked under
+	if got := ic.installExclude("me/tool"); !slices.Equal(got, []string{"docs"}) {
+		t.Errorf("Expected the exclude of the git dependency, got %v", got)
+	}
+
+	// the exclude of a dependency replaces install_exclude for that package
+	keepTarget := filepath.Join(ic.getIndexDir(RealmShared), "me_keep@1.0.0")
+	if err := unzipPackage(archive, keepTarget, "me/keep", ic.extractOptions("me/keep")); err != nil {
+		t.Fatalf("unzipPackage failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(keepTarget, "keep", "docs", "index.md")); err != nil {
+		t.Errorf("Expected the empty exclude to keep docs")
+	}
+
+	sizes, err := ic.PackageSizes()
+	if err != nil {
+		t.Fatalf("PackageSizes failed: %v", err)
+	}
+	// largest first, me/keep kept everything
+	if len(sizes) != 2 || sizes[0].Name != "me_keep@1.0.0" || sizes[1].Name != "me_lib@1.0.0" {
+		t.Fatalf("Expected me_keep then me_lib, got %+v", sizes)
+	}
+	if sizes[0].SkippedFiles != 0 {
+		t.Errorf("Expected nothing skipped for me_keep, got %+v", sizes[0])
+	}
+	skipped := int64(len("-- 20 bytes of tests") + len("on: push\n") + len("# lib\n") + len("print(1)\n"))
+	if sizes[1].SkippedFiles != 4 || sizes[1].Skipped != skipped {
+		t.Errorf("Expected 4 files and %d bytes skipped for me_lib, got %+v", skipped, sizes[1])
+	}
+	if sizes[0].Stale || sizes[1].Stale {
+		t.Errorf("Expected nothing stale right after extracting, got %+v", sizes)
+	}
+
+	// changing the globs leaves the extracted package stale until it's extracted again
+	ic.Manifest.BreadConfig.InstallExclude = []string{".github"}
+	if sizes, err = ic.PackageSizes(); err != nil || !sizes[1].Stale || sizes[0].Stale {
+		t.Fatalf("Expected only me_lib to be stale, got %+v %v", sizes, err)
+	}
+	os.RemoveAll(target)
+	if err := unzipPackage(archive, target, "me/lib", ic.extractOptions("me/lib")); err != nil {
+		t.Fatalf("unzipPackage failed: %v", err)
+	}
+	if sizes, err = ic.PackageSizes(); err != nil || sizes[1].Stale || sizes[1].SkippedFiles != 1 {
+		t.Errorf("Expected me_lib extracted with the new globs, got %+v %v", sizes, err)
+	}
+}
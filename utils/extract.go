@@ -0,0 +1,213 @@
+package utils
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+)
+
+// Defaults for the archive limits in [bread]
+const (
+	DefaultMaxExtractFiles = 10000
+	DefaultMaxExtractBytes = 256 << 20
+	DefaultMaxExtractRatio = 100
+
+	// entries smaller than this aren't held to the ratio, small text files compress very well
+	ratioCheckMinSize = 1 << 20
+)
+
+// extractOptions say what unzipPackage extracts and how much it may write
+type extractOptions struct {
+	exclude  []string
+	maxFiles int
+	maxBytes int64
+	maxRatio int
+
+	// git archives keep the repository's symlinks, links to a file in the package are
+	// extracted as a copy of it and the others skipped. Registry archives can't have links.
+	resolveLinks bool
+}
+
+func (ic *InstallationContext) extractOptions(packageName string) extractOptions {
+	config := ic.Manifest.BreadConfig
+	opts := extractOptions{
+		exclude:  ic.installExclude(packageName),
+		maxFiles: config.MaxExtractFiles,
+		maxBytes: config.MaxExtractBytes,
+		maxRatio: config.MaxExtractRatio,
+	}
+	if opts.maxFiles <= 0 {
+		opts.maxFiles = DefaultMaxExtractFiles
+	}
+	if opts.maxBytes <= 0 {
+		opts.maxBytes = DefaultMaxExtractBytes
+	}
+	if opts.maxRatio <= 0 {
+		opts.maxRatio = DefaultMaxExtractRatio
+	}
+	return opts
+}
+
+// checkArchive rejects an archive before anything is written: too many entries, too large or too
+// compressed, links and special files, paths that leave the package folder, and names that
+// collide, exactly or on a case-insensitive file system. With resolveLinks it returns the
+// symlinks that point at a file in the package, by their path.
+func checkArchive(files []*zip.File, opts extractOptions) (map[string]*zip.File, error) {
+	if len(files) > opts.maxFiles {
+		return nil, fmt.Errorf("archive has %d entries, the limit is %d (max_extract_files)", len(files), opts.maxFiles)
+	}
+
+	type seenPath struct {
+		name string
+		dir  bool
+	}
+	seen := make(map[string]seenPath) // lowercased path -> path
+	note := func(name string, dir bool) error {
+		other, ok := seen[strings.ToLower(name)]
+		switch {
+		case !ok:
+			seen[strings.ToLower(name)] = seenPath{name, dir}
+		case other.name != name:
+			return fmt.Errorf("archive entries %s and %s collide on case-insensitive file systems", other.name, name)
+		case !dir || !other.dir:
+			return fmt.Errorf("archive has %s twice", name)
+		}
+		return nil
+	}
+
+	regular := make(map[string]*zip.File)
+	var symlinks []*zip.File
+	var total uint64
+	for _, f := range files {
+		name, err := archivePath(f.Name)
+		if err != nil {
+			return nil, err
+		}
+
+		switch mode := f.Mode(); {
+		case mode&os.ModeSymlink != 0:
+			if !opts.resolveLinks {
+				return nil, fmt.Errorf("archive entry %s is a symlink", f.Name)
+			}
+			symlinks = append(symlinks, f)
+		case !mode.IsDir() && !mode.IsRegular():
+			return nil, fmt.Errorf("archive entry %s is not a regular file", f.Name)
+		}
+
+		// the folders on the way collide too, src/a.lua and SRC/b.lua end up in one folder
+		for dir := path.Dir(name); dir != "."; dir = path.Dir(dir) {
+			if err := note(dir, true); err != nil {
+				return nil, err
+			}
+		}
+		if err := note(name, f.FileInfo().IsDir()); err != nil {
+			return nil, err
+		}
+
+		if !f.Mode().IsRegular() {
+			continue
+		}
+		regular[name] = f
+
+		total += f.UncompressedSize64
+		if total > uint64(opts.maxBytes) {
+			return nil, fmt.Errorf("archive unpacks to more than %s, the limit (max_extract_bytes)", FormatSize(opts.maxBytes))
+		}
+		if f.UncompressedSize64 >= ratioCheckMinSize && f.UncompressedSize64 > f.CompressedSize64*uint64(opts.maxRatio) {
+			return nil, fmt.Errorf("archive entry %s is compressed over %d:1, the limit (max_extract_ratio)", f.Name, opts.maxRatio)
+		}
+	}
+
+	links, err := archiveLinks(symlinks, regular)
+	if err != nil {
+		return nil, err
+	}
+	// every link is another copy of its file
+	for _, target := range links {
+		total += target.UncompressedSize64
+		if total > uint64(opts.maxBytes) {
+			return nil, fmt.Errorf("archive unpacks to more than %s, the limit (max_extract_bytes)", FormatSize(opts.maxBytes))
+		}
+	}
+	return links, nil
+}
+
+// archiveLinks maps symlink entries to the regular file they point at in the archive.
+// Links that leave the package or point at a folder or another link are left out.
+func archiveLinks(symlinks []*zip.File, regular map[string]*zip.File) (map[string]*zip.File, error) {
+	links := make(map[string]*zip.File)
+	for _, f := range symlinks {
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		target, err := io.ReadAll(io.LimitReader(rc, 4096))
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		name, _ := archivePath(f.Name) // checked by the caller
+		if path.IsAbs(string(target)) {
+			continue
+		}
+		if file, ok := regular[path.Join(path.Dir(name), string(target))]; ok {
+			links[name] = file
+		}
+	}
+	return links, nil
+}
+
+// archivePath cleans an entry name and rejects absolute, drive letter and ../ paths
+func archivePath(name string) (string, error) {
+	if strings.ContainsRune(name, 0) {
+		return "", fmt.Errorf("archive entry %q has a NUL byte in its name", name)
+	}
+
+	// zips made on Windows may use backslashes
+	slashed := strings.ReplaceAll(name, `\`, "/")
+	if strings.HasPrefix(slashed, "/") {
+		return "", fmt.Errorf("archive entry %s has an absolute path", name)
+	}
+	if len(slashed) >= 2 && slashed[1] == ':' {
+		return "", fmt.Errorf("archive entry %s has a drive letter", name)
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("archive entry %s points outside the package", name)
+		}
+	}
+
+	cleaned := path.Clean(slashed)
+	if cleaned == "." {
+		return "", fmt.Errorf("archive entry %q has no name", name)
+	}
+	return cleaned, nil
+}
+
+// extractFile writes one entry, stopping if it holds more than its header said
+func extractFile(f *zip.File, destPath string) error {
+	outFile, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	written, err := io.Copy(outFile, io.LimitReader(rc, int64(f.UncompressedSize64)+1))
+	if err != nil {
+		return err
+	}
+	if written > int64(f.UncompressedSize64) {
+		return fmt.Errorf("archive entry %s is larger than its header says", f.Name)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type zipEntry struct {
+	name    string
+	content string
+	mode    os.FileMode
+	method  uint16
+}
+
+// writeRawZip writes entries in order, with whatever names and modes they have
+func writeRawZip(t *testing.T, path string, entries []zipEntry) {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		header := &zip.FileHeader{Name: e.name, Method: e.method}
+		if e.mode != 0 {
+			header.SetMode(e.mode)
+		}
+		w, err := zw.CreateHeader(header)
+		if err != nil {
+			t.Fatalf("Failed to add %s: %v", e.name, err)
+		}
+		w.Write([]byte(e.content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write zip: %v", err)
+	}
+}
+
+func TestUnzipPackageRejectsMaliciousArchives(t *testing.T) {
+	ok := zipEntry{name: "src/init.lua", content: "return {}\n"}
+	bomb := zipEntry{name: "src/zeros.lua", content: strings.Repeat("\x00", 2<<20), method: zip.Deflate}
+
+	many := []zipEntry{}
+	for i := 0; i < 11; i++ {
+		many = append(many, zipEntry{name: "src/" + strings.Repeat("a", i+1) + ".lua"})
+	}
+
+	tests := []struct {
+		name    string
+		entries []zipEntry
+		limits  extractOptions
+		err     string
+	}{
+		{"symlink", []zipEntry{ok, {name: "src/link.lua", content: "/etc/passwd", mode: os.ModeSymlink | 0777}}, extractOptions{}, "is a symlink"},
+		{"absolute path", []zipEntry{ok, {name: "/etc/cron.d/evil"}}, extractOptions{}, "absolute path"},
+		{"drive letter", []zipEntry{ok, {name: `C:\Windows\evil.lua`}}, extractOptions{}, "drive letter"},
+		{"parent dir", []zipEntry{ok, {name: "src/../../evil.lua"}}, extractOptions{}, "outside the package"},
+		{"backslash parent dir", []zipEntry{ok, {name: `src\..\..\evil.lua`}}, extractOptions{}, "outside the package"},
+		{"duplicate", []zipEntry{ok, {name: "src/./init.lua", content: "evil"}}, extractOptions{}, "twice"},
+		{"case collision", []zipEntry{ok, {name: "src/Init.lua"}}, extractOptions{}, "case-insensitive"},
+		{"folder case collision", []zipEntry{ok, {name: "SRC/util.lua"}}, extractOptions{}, "case-insensitive"},
+		{"file and folder", []zipEntry{ok, {name: "src"}}, extractOptions{}, "twice"},
+		{"too many entries", many, extractOptions{maxFiles: 10}, "max_extract_files"},
+		{"too large", []zipEntry{ok, {name: "src/big.lua", content: strings.Repeat("x", 2048)}}, extractOptions{maxBytes: 1024}, "max_extract_bytes"},
+		{"compression ratio", []zipEntry{ok, bomb}, extractOptions{}, "max_extract_ratio"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			archive := filepath.Join(dir, "package.zip")
+			writeRawZip(t, archive, tt.entries)
+
+			ic := &InstallationContext{}
+			ic.Manifest.BreadConfig.MaxExtractFiles = tt.limits.maxFiles
+			ic.Manifest.BreadConfig.MaxExtractBytes = tt.limits.maxBytes
+
+			target := filepath.Join(dir, "out")
+			err := unzipPackage(archive, target, "me/lib", ic.extractOptions("me/lib"))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Expected an error containing %q, got %v", tt.err, err)
+			}
+
+			// nothing is written for a rejected archive
+			if _, err := os.Stat(target); err == nil {
+				t.Errorf("Expected nothing extracted")
+			}
+		})
+	}
+}
+
+func TestUnzipPackageWithinLimits(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "package.zip")
+	writeRawZip(t, archive, []zipEntry{
+		{name: "src/", mode: os.ModeDir | 0755},
+		{name: "src/init.lua", content: "return {}\n"},
+		// well compressed but small, and a large ratio allowed by config
+		{name: "src/blank.lua", content: strings.Repeat(" ", 4096), method: zip.Deflate},
+		{name: "src/zeros.lua", content: strings.Repeat("\x00", 2<<20), method: zip.Deflate},
+		{name: `lib\util.lua`, content: "return 1\n"},
+	})
+
+	ic := &InstallationContext{}
+	ic.Manifest.BreadConfig.MaxExtractRatio = 10000
+
+	target := filepath.Join(dir, "out")
+	if err := unzipPackage(archive, target, "me/lib", ic.extractOptions("me/lib")); err != nil {
+		t.Fatalf("unzipPackage failed: %v", err)
+	}
+	for _, name := range []string{"src/init.lua", "src/blank.lua", "src/zeros.lua", "lib/util.lua"} {
+		if _, err := os.Stat(filepath.Join(target, "lib", name)); err != nil {
+			t.Errorf("Expected %s to be extracted", name)
+		}
+	}
+}
+
+func TestUnzipPackageResolvesLinks(t *testing.T) {
+	link := os.ModeSymlink | 0777
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "package.zip")
+	writeRawZip(t, archive, []zipEntry{
+		{name: "init.lua", content: "return {}\n"},
+		{name: "alias.lua", content: "init.lua", mode: link},
+		{name: "src/up.lua", content: "../init.lua", mode: link},
+		{name: "outside.lua", content: "../../secret.lua", mode: link},
+		{name: "absolute.lua", content: "/etc/passwd", mode: link},
+		{name: "folder", content: "src", mode: link},
+	})
+
+	opts := (&InstallationContext{}).extractOptions("me/lib")
+	opts.resolveLinks = true
+	target := filepath.Join(dir, "out")
+	if err := unzipPackage(archive, target, "me/lib", opts); err != nil {
+		t.Fatalf("unzipPackage failed: %v", err)
+	}
+
+	for _, name := range []string{"alias.lua", "src/up.lua"} {
+		path := filepath.Join(target, "lib", name)
+		if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+			t.Errorf("Expected %s to be a copy of init.lua, got %v %v", name, info, err)
+		} else if data, _ := os.ReadFile(path); string(data) != "return {}\n" {
+			t.Errorf("Expected %s to hold init.lua, got %q", name, data)
+		}
+	}
+	for _, name := range []string{"outside.lua", "absolute.lua", "folder"} {
+		if _, err := os.Lstat(filepath.Join(target, "lib", name)); err == nil {
+			t.Errorf("Expected %s to be skipped", name)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"yoheiyayoi/bread/breadTypes"
+
+	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/log"
+)
+
+// gitPackage is a package checked out from a git repository at a fixed commit
+type gitPackage struct {
+	Name         string
+	Version      string
+	Commit       string
+	RepoDir      string
+	Dependencies map[string]breadTypes.Dependency
+}
+
+// Only one git process touches the cache at a time
+var gitMu sync.Mutex
+
+func runGit(dir string, args ...string) (string, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("git %s: %s", args[0], msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// gitCacheDir returns where the bare clone of url lives, ~/.bread/git/<hash>
+func gitCacheDir(url string) (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(home, ".bread", "git", hex.EncodeToString(sum[:8])), nil
+}
+
+// syncGitRepo clones url into the cache or fetches new refs.
+// Fetching is skipped when the locked commit is already there, so locked installs work offline.
+func syncGitRepo(url, lockedCommit string) (string, error) {
+	dir, err := gitCacheDir(url)
+	if err != nil {
+		return "", err
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+			return "", err
+		}
+		if _, err := runGit("", "clone", "--bare", "--quiet", url, dir); err != nil {
+			os.RemoveAll(dir)
+			return "", err
+		}
+		return dir, nil
+	}
+
+	if lockedCommit != "" {
+		if _, err := runGit(dir, "cat-file", "-e", lockedCommit+"^{commit}"); err == nil {
+			return dir, nil
+		}
+	}
+
+	if _, err := runGit(dir, "fetch", "--quiet", "--force", "--tags", "origin", "+refs/heads/*:refs/heads/*"); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+// resolveGitCommit turns the rev, tag or branch of dep into a commit hash
+func resolveGitCommit(repoDir string, dep breadTypes.Dependency) (string, error) {
+	kind, ref := dep.GitRef()
+	switch kind {
+	case "tag":
+		ref = "refs/tags/" + ref
+	case "branch":
+		ref = "refs/heads/" + ref
+	case "":
+		ref = "HEAD"
+	}
+
+	commit, err := runGit(repoDir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil || commit == "" {
+		return "", fmt.Errorf("can't find %s in %s", strings.TrimPrefix(dep.Source(), "git+"), dep.Git)
+	}
+	return commit, nil
+}
+
+// lockedGitCommit looks up the commit recorded in bread.lock for dep
+func (ic *InstallationContext) lockedGitCommit(dep breadTypes.Dependency) string {
+	prefix := dep.Source() + "#"
+	for _, versions := range ic.Lockfile {
+		for _, pkg := range versions {
+			if commit, ok := strings.CutPrefix(pkg.Source, prefix); ok {
+				return commit
+			}
+		}
+	}
+	return ""
+}
+
+func (ic *InstallationContext) resolveGitPackage(dep breadTypes.Dependency) (*gitPackage, error) {
+	gitMu.Lock()
+	defer gitMu.Unlock()
+
+	locked := ic.lockedGitCommit(dep)
+	repoDir, err := syncGitRepo(dep.Git, locked)
+	if err != nil {
+		return nil, err
+	}
+
+	commit := locked
+	if commit == "" {
+		if commit, err = resolveGitCommit(repoDir, dep); err != nil {
+			return nil, err
+		}
+	}
+
+	var config breadTypes.Config
+	found := false
+	for _, file := range []string{"bread.toml", "wally.toml"} {
+		content, err := runGit(repoDir, "show", commit+":"+file)
+		if err != nil {
+			continue
+		}
+		if _, err := toml.Decode(content, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse %s in %s: %w", file, dep.Git, err)
+		}
+		found = true
+		break
+	}
+
+	if !found {
+		return nil, fmt.Errorf("no bread.toml or wally.toml found in %s", dep.Git)
+	}
+	if config.Package.Name == "" || config.Package.Version == "" {
+		return nil, fmt.Errorf("package in %s must have a name and version", dep.Git)
+	}
+
+	deps := make(map[string]breadTypes.Dependency, len(config.Dependencies))
+	for name, d := range config.Dependencies {
+		if d.IsPath() {
+			log.Warnf("Ignoring path dependency %s of %s", name, config.Package.Name)
+			continue
+		}
+		deps[name] = d
+	}
+
+	return &gitPackage{
+		Name:         config.Package.Name,
+		Version:      config.Package.Version,
+		Commit:       commit,
+		RepoDir:      repoDir,
+		Dependencies: deps,
+	}, nil
+}
+
+func (ic *InstallationContext) installGitPackage(dep breadTypes.Dependency, realm Realm, session *installSession) {
+	pkg, err := ic.resolveGitPackage(dep)
+	if err != nil {
+		session.fail(dep, err)
+		return
+	}
+	ic.gitPackages.Store(dep.Source(), pkg)
+
+	pkgID := fmt.Sprintf("%s:%s@%s", ic.getIndexDir(realm), pkg.Name, pkg.Version)
+	if _, exists := session.visited.LoadOrStore(pkgID, true); exists {
+		return
+	}
+
+	if err := ic.checkoutGitPackage(pkg, realm); err != nil {
+		session.fail(dep, fmt.Errorf("failed to install %s: %w", dep.Git, err))
+		return
+	}
+
+	n := session.successCount.Add(1)
+	session.msgChan <- pkgInstalledMsg{
+		name:    fmt.Sprintf("%s %s", pkg.Name, versionStyle.Render(pkg.Commit[:min(7, len(pkg.Commit))])),
+		current: int(n),
+		total:   int(session.total.Load()),
+	}
+
+	session.storePackage(pkg.Name, pkg.Version, dep.Source()+"#"+pkg.Commit, ic.sortedDeps(pkg.Dependencies), ic.overriddenAliases(pkg.Dependencies))
+
+	session.total.Add(int32(len(pkg.Dependencies)))
+	for depName, d := range pkg.Dependencies {
+		ic.installPackage(depName, d, realm, session)
+	}
+}
+
+// checkoutGitPackage exports the commit as a zip and extracts it the same way as registry packages
+func (ic *InstallationContext) checkoutGitPackage(pkg *gitPackage, realm Realm) error {
+	tmpFile, err := os.CreateTemp("", "package-*.zip")
+	if err != nil {
+		return err
+	}
+	tmpFile.Close()
+	defer os.Remove(tmpFile.Name())
+
+	gitMu.Lock()
+	_, err = runGit(pkg.RepoDir, "archive", "--format=zip", "-o", tmpFile.Name(), pkg.Commit)
+	gitMu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	targetDir := filepath.Join(ic.getIndexDir(realm), packageIDFileName(pkg.Name, pkg.Version))
+	if err := os.RemoveAll(targetDir); err != nil {
+		return err
+	}
+
+	opts := ic.extractOptions(pkg.Name)
+	opts.resolveLinks = true
+	if err := unzipPackage(tmpFile.Name(), targetDir, pkg.Name, opts); err != nil {
+		return err
+	}
+
+	return ic.applyPatches(pkg.Name, pkg.Version, filepath.Join(targetDir, getPackageName(pkg.Name)))
+}
@@ -0,0 +1,180 @@
+package utils
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+func gitCommitPackage(t *testing.T, repo, version string) string {
+	t.Helper()
+
+	manifest := "[package]\nname = \"me/foo\"\nversion = \"" + version + "\"\n"
+	if err := os.WriteFile(filepath.Join(repo, "bread.toml"), []byte(manifest), 0644); err != nil {
+		t.Fatalf("Failed to write bread.toml: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(repo, "init.lua"), []byte("return \""+version+"\"\n"), 0644); err != nil {
+		t.Fatalf("Failed to write init.lua: %v", err)
+	}
+
+	for _, args := range [][]string{
+		{"add", "-A"},
+		{"-c", "user.name=bread", "-c", "user.email=bread@example.com", "commit", "-q", "-m", version},
+	} {
+		if _, err := runGit(repo, args...); err != nil {
+			t.Fatalf("Failed to commit: %v", err)
+		}
+	}
+
+	commit, err := runGit(repo, "rev-parse", "HEAD")
+	if err != nil {
+		t.Fatalf("Failed to read HEAD: %v", err)
+	}
+	return commit
+}
+
+func TestInstallGitPackage(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+
+	repo := filepath.Join(tmpDir, "foo")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatalf("Failed to create repo dir: %v", err)
+	}
+	if _, err := runGit(repo, "init", "-q", "-b", "main"); err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+
+	tagged := gitCommitPackage(t, repo, "1.0.0")
+	if _, err := runGit(repo, "tag", "v1.0.0"); err != nil {
+		t.Fatalf("Failed to tag: %v", err)
+	}
+	head := gitCommitPackage(t, repo, "1.1.0")
+
+	projectDir := filepath.Join(tmpDir, "project")
+	url := "file://" + filepath.ToSlash(repo)
+
+	tests := []struct {
+		dep     breadTypes.Dependency
+		version string
+		commit  string
+	}{
+		{breadTypes.Dependency{Git: url, Tag: "v1.0.0"}, "1.0.0", tagged},
+		{breadTypes.Dependency{Git: url, Branch: "main"}, "1.1.0", head},
+		{breadTypes.Dependency{Git: url, Rev: tagged}, "1.0.0", tagged},
+	}
+
+	for _, tt := range tests {
+		ic := &InstallationContext{
+			Lockfile:    map[string][]breadTypes.LockedPackage{},
+			ProjectPath: projectDir,
+			SharedDir:   filepath.Join(projectDir, "Packages"),
+		}
+
+		session := newInstallSession(1)
+		ic.installPackage("Foo", tt.dep, RealmShared, session)
+		session.wg.Wait()
+		if err := session.collectErrors(); err != nil {
+			t.Fatalf("Install of %s failed: %v", tt.dep.Source(), err)
+		}
+
+		initPath := filepath.Join(ic.getIndexDir(RealmShared), "me_foo@"+tt.version, "foo", "init.lua")
+		content, err := os.ReadFile(initPath)
+		if err != nil {
+			t.Fatalf("Expected checkout at %s: %v", initPath, err)
+		}
+		if !strings.Contains(string(content), tt.version) {
+			t.Errorf("Expected %s contents, got %q", tt.version, content)
+		}
+
+		locked, ok := session.packages.Load("me/foo@" + tt.version)
+		if !ok {
+			t.Fatalf("Expected me/foo@%s to be locked", tt.version)
+		}
+		if source := locked.(*breadTypes.LockedPackage).Source; source != tt.dep.Source()+"#"+tt.commit {
+			t.Errorf("Expected source pinned to %s, got %s", tt.commit, source)
+		}
+	}
+}
+
+func TestLockedGitCommitIsReused(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+
+	repo := filepath.Join(tmpDir, "foo")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatalf("Failed to create repo dir: %v", err)
+	}
+	if _, err := runGit(repo, "init", "-q", "-b", "main"); err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+
+	first := gitCommitPackage(t, repo, "1.0.0")
+	gitCommitPackage(t, repo, "1.1.0")
+
+	dep := breadTypes.Dependency{Git: "file://" + filepath.ToSlash(repo), Branch: "main"}
+	ic := &InstallationContext{
+		Lockfile: map[string][]breadTypes.LockedPackage{
+			"me/foo": {{Name: "me/foo", Version: "1.0.0", Source: dep.Source() + "#" + first}},
+		},
+	}
+
+	pkg, err := ic.resolveGitPackage(dep)
+	if err != nil {
+		t.Fatalf("resolveGitPackage failed: %v", err)
+	}
+	if pkg.Commit != first || pkg.Version != "1.0.0" {
+		t.Errorf("Expected locked commit %s (1.0.0), got %s (%s)", first, pkg.Commit, pkg.Version)
+	}
+}
+
+func TestInstallGitPackageWithSymlink(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", filepath.Join(tmpDir, "home"))
+
+	repo := filepath.Join(tmpDir, "foo")
+	if err := os.MkdirAll(repo, 0755); err != nil {
+		t.Fatalf("Failed to create repo dir: %v", err)
+	}
+	if _, err := runGit(repo, "init", "-q", "-b", "main"); err != nil {
+		t.Fatalf("Failed to init repo: %v", err)
+	}
+	if err := os.Symlink("init.lua", filepath.Join(repo, "alias.lua")); err != nil {
+		t.Skipf("symlinks not available: %v", err)
+	}
+	gitCommitPackage(t, repo, "1.0.0")
+
+	projectDir := filepath.Join(tmpDir, "project")
+	ic := &InstallationContext{
+		Lockfile:    map[string][]breadTypes.LockedPackage{},
+		ProjectPath: projectDir,
+		SharedDir:   filepath.Join(projectDir, "Packages"),
+	}
+
+	session := newInstallSession(1)
+	ic.installPackage("Foo", breadTypes.Dependency{Git: "file://" + filepath.ToSlash(repo)}, RealmShared, session)
+	session.wg.Wait()
+	if err := session.collectErrors(); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	alias := filepath.Join(ic.getIndexDir(RealmShared), "me_foo@1.0.0", "foo", "alias.lua")
+	if data, err := os.ReadFile(alias); err != nil || string(data) != "return \"1.0.0\"\n" {
+		t.Errorf("Expected alias.lua to hold init.lua, got %q %v", data, err)
+	}
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/BurntSushi/toml"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/log"
+	"golang.org/x/mod/semver"
 )
 
 type installSession struct {
@@ -49,32 +50,101 @@ func (s *installSession) collectErrors() error {
 	return nil
 }
 
-func (s *installSession) storePackage(name, version string, deps [][]string) {
+// fail records an install error. Optional dependencies only log a warning.
+func (s *installSession) fail(dep breadTypes.Dependency, err error) {
+	if dep.Optional {
+		log.Warnf("Skipping optional dependency: %s", err)
+		return
+	}
+	s.errors <- err
+}
+
+func (s *installSession) storePackage(name, version, source string, deps [][]string, overridden []string) {
 	key := fmt.Sprintf("%s@%s", name, version)
 	s.packages.Store(key, &breadTypes.LockedPackage{
 		Name:         name,
 		Version:      version,
+		Source:       source,
 		Dependencies: deps,
+		Overridden:   overridden,
+	})
+}
+
+// resolvedVersion is the version this install picked for name within constraint, the highest if several were
+func (s *installSession) resolvedVersion(name, constraint string) (string, bool) {
+	best := ""
+	s.packages.Range(func(_, value interface{}) bool {
+		pkg := value.(*breadTypes.LockedPackage)
+		if pkg.Name == name && MatchConstraint(pkg.Version, constraint) {
+			if best == "" || semver.Compare("v"+pkg.Version, "v"+best) > 0 {
+				best = pkg.Version
+			}
+		}
+		return true
 	})
+	return best, best != ""
+}
+
+// realmDependencies are the root dependencies installed into one realm
+type realmDependencies struct {
+	realm Realm
+	deps  map[string]breadTypes.Dependency
+}
+
+// dependenciesByRealm groups the manifest dependency tables by realm.
+// A dependency with its own realm moves out of the table it's listed in.
+func (ic *InstallationContext) dependenciesByRealm() []realmDependencies {
+	realms := []realmDependencies{
+		{RealmShared, make(map[string]breadTypes.Dependency)},
+		{RealmServer, make(map[string]breadTypes.Dependency)},
+		{RealmDev, make(map[string]breadTypes.Dependency)},
+	}
+	tables := []map[string]breadTypes.Dependency{
+		ic.Manifest.Dependencies,
+		ic.Manifest.ServerDependencies,
+		ic.Manifest.DevDependencies,
+	}
+
+	for i, table := range tables {
+		for name, dep := range table {
+			realm := realms[i].realm
+			if dep.Realm != "" {
+				realm = Realm(dep.Realm)
+			}
+
+			for _, r := range realms {
+				if r.realm == realm {
+					r.deps[name] = dep
+				}
+			}
+		}
+	}
+
+	return realms
 }
 
 // Install downloads and links all dependencies from the manifest.
+// In a workspace every member is installed too, sharing one lockfile.
 func (ic *InstallationContext) Install() error {
 	start := time.Now()
 
-	realms := []struct {
-		realm Realm
-		deps  map[string]string
-	}{
-		{RealmShared, ic.Manifest.Dependencies},
-		{RealmServer, ic.Manifest.ServerDependencies},
-		{RealmDev, ic.Manifest.DevDependencies},
+	targets := ic.installTargets()
+	for _, target := range targets {
+		if err := target.RunHook(PreinstallScript); err != nil {
+			return err
+		}
+	}
+
+	realms := make([][]realmDependencies, len(targets))
+	total := 0
+	for i, target := range targets {
+		realms[i] = target.dependenciesByRealm()
+		total += countDependencies(realms[i])
 	}
 
-	total := countDependencies(realms)
 	if total == 0 {
 		log.Info("No packages to install")
-		return nil
+		return runPostinstall(targets)
 	}
 
 	log.Info("Installing packages...")
@@ -85,9 +155,11 @@ func (ic *InstallationContext) Install() error {
 	session.program = p
 
 	go func() {
-		if err := ic.downloadAll(realms, session); err != nil {
-			session.msgChan <- installFinishedMsg{err}
-			return
+		for i, target := range targets {
+			if err := target.downloadAll(realms[i], session); err != nil {
+				session.msgChan <- installFinishedMsg{err}
+				return
+			}
 		}
 
 		session.wg.Wait()
@@ -102,23 +174,56 @@ func (ic *InstallationContext) Install() error {
 		return err
 	}
 
-	if err := ic.writeLockfile(session); err != nil {
+	if err := ic.writeLockfile(session, targets); err != nil {
 		return err
 	}
 
-	if err := ic.linkAll(realms); err != nil {
-		return err
+	for i, target := range targets {
+		if err := target.linkAll(realms[i], session); err != nil {
+			return err
+		}
+	}
+
+	// Keep the Rojo project, sourcemap and .luaurc pointing at the package folders
+	for _, target := range targets {
+		if target.Manifest.BreadConfig.RojoProject != "" {
+			if _, _, err := target.SyncRojoProject(); err != nil {
+				return err
+			}
+		}
+		if target.Manifest.BreadConfig.Sourcemap {
+			if _, err := target.WriteSourcemap(); err != nil {
+				return err
+			}
+		}
+		if target.Manifest.BreadConfig.LuaurcAliases || target.Manifest.BreadConfig.LuaurcPackages {
+			if _, err := target.WriteLuaurc(); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i, target := range targets {
+		if err := target.runDependencyScripts(realms[i]); err != nil {
+			return err
+		}
 	}
 
 	elapsed := time.Since(start)
 	log.Infof("%s Installed %d packages in %.2fs [%dms]", Check, session.successCount.Load(), elapsed.Seconds(), elapsed.Milliseconds())
+	return runPostinstall(targets)
+}
+
+func runPostinstall(targets []*InstallationContext) error {
+	for _, target := range targets {
+		if err := target.RunHook(PostinstallScript); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-func countDependencies(realms []struct {
-	realm Realm
-	deps  map[string]string
-}) int {
+func countDependencies(realms []realmDependencies) int {
 	total := 0
 	for _, r := range realms {
 		total += len(r.deps)
@@ -126,10 +231,7 @@ func countDependencies(realms []struct {
 	return total
 }
 
-func (ic *InstallationContext) downloadAll(realms []struct {
-	realm Realm
-	deps  map[string]string
-}, session *installSession) error {
+func (ic *InstallationContext) downloadAll(realms []realmDependencies, session *installSession) error {
 	for _, r := range realms {
 		if len(r.deps) == 0 {
 			continue
@@ -139,20 +241,17 @@ func (ic *InstallationContext) downloadAll(realms []struct {
 			return err
 		}
 
-		for name, spec := range r.deps {
-			ic.installPackage(name, spec, r.realm, session)
+		for name, dep := range r.deps {
+			ic.installPackage(name, dep, r.realm, session)
 		}
 	}
 	return nil
 }
 
-func (ic *InstallationContext) linkAll(realms []struct {
-	realm Realm
-	deps  map[string]string
-}) error {
+func (ic *InstallationContext) linkAll(realms []realmDependencies, session *installSession) error {
 	for _, r := range realms {
 		if len(r.deps) > 0 {
-			if err := ic.writeRootPackageLinks(r.realm, r.deps); err != nil {
+			if err := ic.writeRootPackageLinks(r.realm, r.deps, session); err != nil {
 				return err
 			}
 		}
@@ -160,26 +259,45 @@ func (ic *InstallationContext) linkAll(realms []struct {
 	return nil
 }
 
-func (ic *InstallationContext) installPackage(name, spec string, realm Realm, session *installSession) {
+func (ic *InstallationContext) installPackage(name string, dep breadTypes.Dependency, realm Realm, session *installSession) {
 	session.wg.Go(func() {
-		pkgName, constraint := ParsePackageSpec(name, spec)
+		switch {
+		case dep.IsPath():
+			ic.installLocalPackage(dep, realm, session)
+			return
+		case dep.IsGit():
+			ic.installGitPackage(dep, realm, session)
+			return
+		}
+
+		dep, _ = applyOverride(NormalizeDependency(name, dep), ic.overrides)
+		if member := ic.resolveWorkspaceDependency(dep); member.IsPath() {
+			ic.installLocalPackage(member, realm, session)
+			return
+		}
+
+		api, err := RegistryAPI(dep.Registry)
+		if err != nil {
+			session.fail(dep, err)
+			return
+		}
 
-		version, err := ic.resolveVersion(pkgName, constraint)
+		version, err := ic.resolveVersion(api, dep.Package, dep.Version)
 		if err != nil {
-			session.errors <- err
+			session.fail(dep, err)
 			return
 		}
 
-		pkgID := fmt.Sprintf("%s:%s@%s", realm, pkgName, version)
+		pkgID := fmt.Sprintf("%s:%s@%s", ic.getIndexDir(realm), dep.Package, version)
 		if _, exists := session.visited.LoadOrStore(pkgID, true); exists {
 			return
 		}
 
-		ic.downloadAndProcessPackage(pkgName, version, realm, session)
+		ic.downloadAndProcessPackage(dep, api, version, realm, session)
 	})
 }
 
-func (ic *InstallationContext) resolveVersion(name, constraint string) (string, error) {
+func (ic *InstallationContext) resolveVersion(api, name, constraint string) (string, error) {
 	// Check lockfile first
 	if locked, ok := ic.Lockfile[name]; ok {
 		for _, pkg := range locked {
@@ -189,16 +307,17 @@ func (ic *InstallationContext) resolveVersion(name, constraint string) (string,
 		}
 	}
 
-	version, err := ResolveVersion(name, constraint)
+	version, err := ResolveVersion(api, name, constraint)
 	if err != nil {
 		return "", fmt.Errorf("failed to resolve %s@%s: %w", name, constraint, err)
 	}
 	return version, nil
 }
 
-func (ic *InstallationContext) downloadAndProcessPackage(name, version string, realm Realm, session *installSession) {
-	if err := ic.downloadPackage(name, version, realm); err != nil {
-		session.errors <- err
+func (ic *InstallationContext) downloadAndProcessPackage(dep breadTypes.Dependency, api, version string, realm Realm, session *installSession) {
+	name := dep.Package
+	if err := ic.downloadPackage(api, name, version, realm); err != nil {
+		session.fail(dep, err)
 		return
 	}
 
@@ -215,19 +334,19 @@ func (ic *InstallationContext) downloadAndProcessPackage(name, version string, r
 		return
 	}
 
-	depsList := sortedDeps(deps)
-	session.storePackage(name, version, depsList)
+	depsList := ic.sortedDeps(deps)
+	session.storePackage(name, version, dep.Source(), depsList, ic.overriddenAliases(deps))
 
 	session.total.Add(int32(len(deps)))
-	for depName, depSpec := range deps {
-		ic.installPackage(depName, depSpec, realm, session)
+	for depName, dep := range deps {
+		ic.installPackage(depName, dep, realm, session)
 	}
 }
 
-func sortedDeps(deps map[string]string) [][]string {
+func (ic *InstallationContext) sortedDeps(deps map[string]breadTypes.Dependency) [][]string {
 	result := make([][]string, 0, len(deps))
-	for name, spec := range deps {
-		result = append(result, []string{name, spec})
+	for name, dep := range deps {
+		result = append(result, []string{name, ic.edgeSpec(name, dep)})
 	}
 	sort.Slice(result, func(i, j int) bool {
 		return result[i][0] < result[j][0]
@@ -235,16 +354,22 @@ func sortedDeps(deps map[string]string) [][]string {
 	return result
 }
 
-func (ic *InstallationContext) writeLockfile(session *installSession) error {
+func (ic *InstallationContext) writeLockfile(session *installSession, targets []*InstallationContext) error {
+	// Root packages replace their entry if another workspace member installed them by path
+	for _, target := range targets {
+		if root := target.createRootPackage(); root.Name != "" {
+			session.packages.Store(fmt.Sprintf("%s@%s", root.Name, root.Version), &root)
+		}
+	}
+
 	packages := ic.collectLockedPackages(session)
-	packages = append(packages, ic.createRootPackage())
 
 	sort.Slice(packages, func(i, j int) bool {
 		return packages[i].Name < packages[j].Name
 	})
 
 	lockfile := breadTypes.Lockfile{
-		Registry: "test",
+		Registry: lockRegistry(&ic.Manifest),
 		Packages: packages,
 	}
 
@@ -262,16 +387,19 @@ func (ic *InstallationContext) collectLockedPackages(session *installSession) []
 
 func (ic *InstallationContext) createRootPackage() breadTypes.LockedPackage {
 	var deps [][]string
-
-	for name, spec := range ic.Manifest.Dependencies {
-		deps = append(deps, []string{name, spec})
-	}
-	for name, spec := range ic.Manifest.ServerDependencies {
-		deps = append(deps, []string{name, spec})
-	}
-	for name, spec := range ic.Manifest.DevDependencies {
-		deps = append(deps, []string{name, spec})
+	var overridden []string
+
+	for _, table := range []map[string]breadTypes.Dependency{
+		ic.Manifest.Dependencies,
+		ic.Manifest.ServerDependencies,
+		ic.Manifest.DevDependencies,
+	} {
+		for name, dep := range table {
+			deps = append(deps, []string{name, ic.edgeSpec(name, dep)})
+		}
+		overridden = append(overridden, ic.overriddenAliases(table)...)
 	}
+	sort.Strings(overridden)
 
 	sort.Slice(deps, func(i, j int) bool {
 		return deps[i][0] < deps[j][0]
@@ -281,19 +409,32 @@ func (ic *InstallationContext) createRootPackage() breadTypes.LockedPackage {
 		Name:         ic.Manifest.Package.Name,
 		Version:      ic.Manifest.Package.Version,
 		Dependencies: deps,
+		Overridden:   overridden,
 	}
 }
 
 func (ic *InstallationContext) saveLockfile(lockfile breadTypes.Lockfile) error {
 	path := filepath.Join(ic.ProjectPath, "bread.lock")
 
+	// tools are pinned by bread tools install, installing packages keeps them
+	if lockfile.Tools == nil {
+		if existing, err := readLockfile(path); err == nil && existing != nil {
+			lockfile.Tools = existing.Tools
+		}
+	}
+
+	return writeLockfileTo(path, "Bread", lockfile)
+}
+
+// writeLockfileTo writes a lockfile with the header of the tool it's for, Bread or Wally
+func writeLockfileTo(path, generatedBy string, lockfile breadTypes.Lockfile) error {
 	f, err := os.Create(path)
 	if err != nil {
 		return fmt.Errorf("failed to create lockfile: %w", err)
 	}
 	defer f.Close()
 
-	header := "# This file is automatically @generated by Bread.\n# It is not intended for manual editing.\n\n"
+	header := fmt.Sprintf("# This file is automatically @generated by %s.\n# It is not intended for manual editing.\n\n", generatedBy)
 	if _, err := f.WriteString(header); err != nil {
 		return fmt.Errorf("failed to write lockfile header: %w", err)
 	}
@@ -321,7 +462,7 @@ func (ic *InstallationContext) InstallSinglePackage(name, versionSpec string, re
 			return
 		}
 
-		ic.installPackage(name, versionSpec, realm, session)
+		ic.installPackage(name, breadTypes.Dependency{Spec: versionSpec}, realm, session)
 
 		session.wg.Wait()
 		session.msgChan <- installFinishedMsg{nil}
@@ -336,7 +477,7 @@ func (ic *InstallationContext) InstallSinglePackage(name, versionSpec string, re
 	}
 
 	pkgName, constraint := ParsePackageSpec(name, versionSpec)
-	version, err := ic.resolveVersion(pkgName, constraint)
+	version, err := ic.resolveVersion(DefaultRegistryAPI, pkgName, constraint)
 	if err != nil {
 		return err
 	}
@@ -0,0 +1,231 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// redirectTransport sends every request to the test server
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (rt redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme, req.URL.Host = rt.target.Scheme, rt.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// serveWallyAPI answers the downloads of ic from packages, keyed "scope/name@version".
+// The version lists are seeded too, so nothing reaches the real index.
+func serveWallyAPI(t *testing.T, ic *InstallationContext, packages map[string]map[string]string) {
+	t.Helper()
+
+	archives := make(map[string][]byte)
+	versions := make(map[string][]string)
+	for id, files := range packages {
+		name, version, _ := strings.Cut(id, "@")
+		versions[name] = append(versions[name], version)
+
+		var archive bytes.Buffer
+		zw := zip.NewWriter(&archive)
+		for file, content := range files {
+			w, err := zw.Create(file)
+			if err != nil {
+				t.Fatalf("Failed to add %s: %v", file, err)
+			}
+			w.Write([]byte(content))
+		}
+		zw.Close()
+		archives["/v1/package-contents/"+name+"/"+version] = archive.Bytes()
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		archive, ok := archives[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(archive)
+	}))
+	t.Cleanup(server.Close)
+
+	target, _ := url.Parse(server.URL)
+	ic.Client = &http.Client{Transport: redirectTransport{target}}
+
+	metadataMu.Lock()
+	for name, list := range versions {
+		slices.Sort(list)
+		metadataCache[DefaultRegistryAPI+"|"+name] = list
+	}
+	metadataMu.Unlock()
+	t.Cleanup(func() {
+		metadataMu.Lock()
+		for name := range versions {
+			delete(metadataCache, DefaultRegistryAPI+"|"+name)
+		}
+		metadataMu.Unlock()
+	})
+}
+
+// installAll runs what Install does without the progress UI
+func installAll(t *testing.T, ic *InstallationContext) {
+	t.Helper()
+
+	realms := ic.dependenciesByRealm()
+	session := newInstallSession(countDependencies(realms))
+	if err := ic.downloadAll(realms, session); err != nil {
+		t.Fatalf("downloadAll failed: %v", err)
+	}
+	session.wg.Wait()
+	if err := session.collectErrors(); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if err := ic.writeLockfile(session, []*InstallationContext{ic}); err != nil {
+		t.Fatalf("writeLockfile failed: %v", err)
+	}
+	if err := ic.linkAll(realms, session); err != nil {
+		t.Fatalf("linkAll failed: %v", err)
+	}
+}
+
+func TestInstallWallyProject(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"wally.toml": `[package]
+name = "me/game"
+version = "0.1.0"
+realm = "shared"
+
+[place]
+shared-packages = "game.ReplicatedStorage.Shared"
+server-packages = "game.ServerScriptService.Server"
+
+[dependencies]
+Signal = "sleitnick/signal@^1.0.0"
+
+[server-dependencies]
+Store = "me/store@^2.0.0"
+`,
+	})
+
+	if got := ManifestPath(dir); got != filepath.Join(dir, "wally.toml") {
+		t.Errorf("Expected wally.toml as the manifest, got %s", got)
+	}
+	manifest, err := readPackageManifest(dir)
+	if err != nil || manifest == nil || manifest.Place == nil || manifest.Place.SharedPackages != "game.ReplicatedStorage.Shared" {
+		t.Fatalf("Expected [place] to be read from wally.toml, got %+v %v", manifest, err)
+	}
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	if ic.SharedPath == nil || *ic.SharedPath != "game.ReplicatedStorage.Shared" ||
+		ic.ServerPath == nil || *ic.ServerPath != "game.ServerScriptService.Server" {
+		t.Errorf("Expected the places from [place], got %v %v", ic.SharedPath, ic.ServerPath)
+	}
+	serveWallyAPI(t, ic, map[string]map[string]string{
+		"sleitnick/signal@1.2.0": {"init.lua": "return {}\n"},
+		"me/store@2.0.0":         {"init.lua": "return {}\n"},
+	})
+
+	installAll(t, ic)
+
+	for _, file := range []string{
+		"Packages/_Index/sleitnick_signal@1.2.0/signal/init.lua",
+		"Packages/signal.lua",
+		"ServerPackages/_Index/me_store@2.0.0/store/init.lua",
+		"ServerPackages/store.lua",
+	} {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Errorf("Expected %s to be installed: %v", file, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "bread.toml")); err == nil {
+		t.Errorf("Expected the install to leave the manifest as wally.toml")
+	}
+
+	lockfile, err := readLockfile(filepath.Join(dir, "bread.lock"))
+	if err != nil || lockfile == nil || len(lockfile.Packages) != 3 {
+		t.Fatalf("Expected both packages and the project locked, got %+v %v", lockfile, err)
+	}
+
+	// the Rojo mounts follow [place]
+	var mounts [][]string
+	for _, mount := range ic.rojoMounts() {
+		mounts = append(mounts, mount.instance)
+	}
+	expected := [][]string{{"ReplicatedStorage", "Shared"}, {"ServerScriptService", "Server"}}
+	if !slices.EqualFunc(mounts, expected, slices.Equal) {
+		t.Errorf("Expected mounts %v, got %v", expected, mounts)
+	}
+
+	// a folder given on the command line wins over [place]
+	flag := "game.ReplicatedStorage.Vendor"
+	if ic := NewInstaller(dir, &flag, nil); ic == nil || *ic.SharedPath != flag {
+		t.Errorf("Expected the command line place to be kept")
+	}
+}
+
+func TestInstallKeepsWallyLockVersions(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"wally.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\nrealm = \"shared\"\nregistry = \"" + DefaultRegistry + "\"\n\n" +
+			"[dependencies]\nSignal = \"sleitnick/signal@^1.0.0\"\n",
+		"wally.lock": "registry = \"" + DefaultRegistry + "\"\n\n" +
+			"[[package]]\nname = \"me/game\"\nversion = \"0.1.0\"\ndependencies = [[\"Signal\", \"sleitnick/signal@1.2.0\"]]\n\n" +
+			"[[package]]\nname = \"sleitnick/signal\"\nversion = \"1.2.0\"\ndependencies = []\n",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	// 1.3.0 matches too, but wally.lock has 1.2.0
+	serveWallyAPI(t, ic, map[string]map[string]string{
+		"sleitnick/signal@1.2.0": {"init.lua": "return {}\n"},
+		"sleitnick/signal@1.3.0": {"init.lua": "return {}\n"},
+	})
+
+	installAll(t, ic)
+
+	if _, err := os.Stat(filepath.Join(dir, "Packages", "_Index", "sleitnick_signal@1.2.0")); err != nil {
+		t.Errorf("Expected the version from wally.lock to be installed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Packages", "_Index", "sleitnick_signal@1.3.0")); err == nil {
+		t.Errorf("Expected the newer version to be left alone")
+	}
+
+	lockfile, err := readLockfile(filepath.Join(dir, "bread.lock"))
+	if err != nil || lockfile == nil {
+		t.Fatalf("Failed to read bread.lock: %v", err)
+	}
+	if lockfile.Registry != DefaultRegistry {
+		t.Errorf("Expected bread.lock to record %s, got %q", DefaultRegistry, lockfile.Registry)
+	}
+	if len(lockfile.Packages) != 2 || lockfile.Packages[1].Name != "sleitnick/signal" || lockfile.Packages[1].Version != "1.2.0" {
+		t.Errorf("Expected sleitnick/signal to stay at 1.2.0, got %+v", lockfile.Packages)
+	}
+
+	// exporting gives back the lockfile Wally wrote
+	os.Remove(filepath.Join(dir, "wally.lock"))
+	if _, err := ExportWallyLock(dir); err != nil {
+		t.Fatalf("ExportWallyLock failed: %v", err)
+	}
+	wallyLock, err := readLockfile(filepath.Join(dir, "wally.lock"))
+	if err != nil || wallyLock == nil || wallyLock.Registry != DefaultRegistry {
+		t.Fatalf("Expected wally.lock to keep the registry, got %+v %v", wallyLock, err)
+	}
+	if root := wallyLock.Packages[0]; root.Name != "me/game" || root.Dependencies[0][1] != "sleitnick/signal@1.2.0" {
+		t.Errorf("Unexpected root package in wally.lock: %+v", root)
+	}
+}
@@ -0,0 +1,244 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// jsonEditor sets values in a JSON document by splicing the text,
+// everything around the edited value keeps its order and formatting
+type jsonEditor struct {
+	src    []byte
+	indent string // one level of indentation, as the document uses it
+}
+
+type jsonMember struct {
+	key              string
+	keyStart         int
+	valStart, valEnd int
+}
+
+func newJSONEditor(src []byte) *jsonEditor {
+	e := &jsonEditor{src: src, indent: "  "}
+	for _, line := range strings.Split(string(src), "\n") {
+		trimmed := strings.TrimLeft(line, " \t")
+		if trimmed != "" && len(trimmed) < len(line) {
+			e.indent = line[:len(line)-len(trimmed)]
+			break
+		}
+	}
+	return e
+}
+
+// set writes value (JSON text) at the key path, creating the objects on the way
+func (e *jsonEditor) set(path []string, value string) error {
+	pos := e.skipSpace(0)
+	if pos >= len(e.src) || e.src[pos] != '{' {
+		return fmt.Errorf("expected a JSON object")
+	}
+
+	for i, key := range path {
+		members, closeIdx, err := e.members(pos)
+		if err != nil {
+			return err
+		}
+
+		var found *jsonMember
+		for j := range members {
+			if members[j].key == key {
+				found = &members[j]
+				break
+			}
+		}
+
+		if found == nil {
+			e.insert(pos, closeIdx, members, key, path[i+1:], value)
+			return nil
+		}
+
+		if i == len(path)-1 {
+			e.splice(found.valStart, found.valEnd, value)
+			return nil
+		}
+
+		if e.src[found.valStart] != '{' {
+			return fmt.Errorf("%s is not an object", strings.Join(path[:i+1], "."))
+		}
+		pos = found.valStart
+	}
+
+	return nil
+}
+
+// insert adds a member at the end of the object at pos
+func (e *jsonEditor) insert(pos, closeIdx int, members []jsonMember, key string, rest []string, value string) {
+	if len(members) == 0 {
+		outer := e.lineIndent(pos)
+		inner := outer + e.indent
+		text := "{\n" + inner + jsonQuote(key) + ": " + e.nested(rest, value, inner) + "\n" + outer + "}"
+		e.splice(pos, closeIdx+1, text)
+		return
+	}
+
+	last := members[len(members)-1]
+	indent := e.lineIndent(last.keyStart)
+	text := ",\n" + indent + jsonQuote(key) + ": " + e.nested(rest, value, indent)
+	e.splice(last.valEnd, last.valEnd, text)
+}
+
+// nested builds {"a": {"b": value}} for the keys left, indented below a member at indent
+func (e *jsonEditor) nested(keys []string, value, indent string) string {
+	if len(keys) == 0 {
+		return value
+	}
+	inner := indent + e.indent
+	return "{\n" + inner + jsonQuote(keys[0]) + ": " + e.nested(keys[1:], value, inner) + "\n" + indent + "}"
+}
+
+func (e *jsonEditor) splice(start, end int, text string) {
+	e.src = append(e.src[:start:start], append([]byte(text), e.src[end:]...)...)
+}
+
+// lineIndent returns the whitespace the line containing i starts with
+func (e *jsonEditor) lineIndent(i int) string {
+	start := i
+	for start > 0 && e.src[start-1] != '\n' {
+		start--
+	}
+	end := start
+	for end < len(e.src) && (e.src[end] == ' ' || e.src[end] == '\t') {
+		end++
+	}
+	return string(e.src[start:end])
+}
+
+// members lists the members of the object at pos and returns the index of its "}"
+func (e *jsonEditor) members(pos int) ([]jsonMember, int, error) {
+	var members []jsonMember
+	i := e.skipSpace(pos + 1)
+
+	for i < len(e.src) && e.src[i] != '}' {
+		if e.src[i] != '"' {
+			return nil, 0, fmt.Errorf("expected a key at offset %d", i)
+		}
+		keyEnd, err := e.scanString(i)
+		if err != nil {
+			return nil, 0, err
+		}
+		var key string
+		if err := json.Unmarshal(e.src[i:keyEnd], &key); err != nil {
+			return nil, 0, err
+		}
+
+		colon := e.skipSpace(keyEnd)
+		if colon >= len(e.src) || e.src[colon] != ':' {
+			return nil, 0, fmt.Errorf("expected ':' at offset %d", colon)
+		}
+
+		valStart := e.skipSpace(colon + 1)
+		valEnd, err := e.scanValue(valStart)
+		if err != nil {
+			return nil, 0, err
+		}
+		members = append(members, jsonMember{key: key, keyStart: i, valStart: valStart, valEnd: valEnd})
+
+		i = e.skipSpace(valEnd)
+		if i < len(e.src) && e.src[i] == ',' {
+			i = e.skipSpace(i + 1)
+		}
+	}
+
+	if i >= len(e.src) {
+		return nil, 0, fmt.Errorf("unterminated object")
+	}
+	return members, i, nil
+}
+
+// scanValue returns the index after the value starting at i
+func (e *jsonEditor) scanValue(i int) (int, error) {
+	if i >= len(e.src) {
+		return 0, fmt.Errorf("unexpected end of JSON")
+	}
+
+	switch e.src[i] {
+	case '"':
+		return e.scanString(i)
+	case '{', '[':
+		depth := 0
+		for j := i; j < len(e.src); j++ {
+			switch e.src[j] {
+			case '"':
+				end, err := e.scanString(j)
+				if err != nil {
+					return 0, err
+				}
+				j = end - 1
+			case '/':
+				end := e.skipSpace(j)
+				if end == j {
+					return 0, fmt.Errorf("unexpected '/' at offset %d", j)
+				}
+				j = end - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				depth--
+				if depth == 0 {
+					return j + 1, nil
+				}
+			}
+		}
+		return 0, fmt.Errorf("unterminated value at offset %d", i)
+	default:
+		j := i
+		for j < len(e.src) && !strings.ContainsRune(",}] \t\r\n", rune(e.src[j])) {
+			j++
+		}
+		return j, nil
+	}
+}
+
+// scanString returns the index after the string starting at i
+func (e *jsonEditor) scanString(i int) (int, error) {
+	for j := i + 1; j < len(e.src); j++ {
+		switch e.src[j] {
+		case '\\':
+			j++
+		case '"':
+			return j + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated string at offset %d", i)
+}
+
+// skipSpace skips whitespace and the // and /* */ comments .luaurc files may have
+func (e *jsonEditor) skipSpace(i int) int {
+	for i < len(e.src) {
+		switch {
+		case strings.ContainsRune(" \t\r\n", rune(e.src[i])):
+			i++
+		case bytes.HasPrefix(e.src[i:], []byte("//")):
+			end := bytes.IndexByte(e.src[i:], '\n')
+			if end < 0 {
+				return len(e.src)
+			}
+			i += end + 1
+		case bytes.HasPrefix(e.src[i:], []byte("/*")):
+			end := bytes.Index(e.src[i+2:], []byte("*/"))
+			if end < 0 {
+				return len(e.src)
+			}
+			i += end + 4
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+func jsonQuote(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
@@ -0,0 +1,25 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestJSONEditorSkipsComments(t *testing.T) {
+	src := "{\n  // aliases\n  \"tree\": {\"a\": [1, /* two */ 2]}\n}\n"
+	e := newJSONEditor([]byte(src))
+	if err := e.set([]string{"tree", "b"}, "3"); err != nil {
+		t.Fatalf("set failed: %v", err)
+	}
+	if got := string(e.src); !strings.Contains(got, "/* two */ 2]") || !strings.Contains(got, "\"b\": 3") {
+		t.Errorf("Expected b added and the comments kept, got:\n%s", got)
+	}
+}
+
+func TestJSONEditorStraySlash(t *testing.T) {
+	// a '/' starting no comment used to loop forever
+	e := newJSONEditor([]byte(`{"tree": {"a": [1 / 2]}}`))
+	if err := e.set([]string{"tree", "b"}, "3"); err == nil || !strings.Contains(err.Error(), "unexpected '/' at offset 18") {
+		t.Errorf("Expected an error for the stray '/', got %v", err)
+	}
+}
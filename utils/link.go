@@ -0,0 +1,272 @@
+package utils
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/charmbracelet/log"
+	"golang.org/x/mod/semver"
+)
+
+// linksFile is the global list of libraries registered with bread link, ~/.bread/links.json
+func linksFile() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".bread", "links.json"), nil
+}
+
+// projectLinksFile lists the packages bread link replaced in this project, so installs keep them linked
+func (ic *InstallationContext) projectLinksFile() string {
+	return filepath.Join(ic.ProjectPath, ".bread", "links.json")
+}
+
+// loadLinks returns the global links, package name -> library directory
+func loadLinks() (map[string]string, error) {
+	path, err := linksFile()
+	if err != nil {
+		return nil, err
+	}
+	return readLinks(path)
+}
+
+func saveLinks(links map[string]string) error {
+	path, err := linksFile()
+	if err != nil {
+		return err
+	}
+	return writeLinks(path, links)
+}
+
+func readLinks(path string) (map[string]string, error) {
+	links := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return links, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &links); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return links, nil
+}
+
+func writeLinks(path string, links map[string]string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(links, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// RegisterLink makes the library in dir available to bread link <name>. Returns the package name.
+func RegisterLink(dir string) (string, error) {
+	return updateLink(dir, true)
+}
+
+// UnregisterLink removes the library in dir from the global links. Returns the package name.
+func UnregisterLink(dir string) (string, error) {
+	return updateLink(dir, false)
+}
+
+func updateLink(dir string, register bool) (string, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+
+	config, err := readPackageManifest(dir)
+	if err != nil {
+		return "", err
+	}
+	if config == nil || config.Package.Name == "" {
+		return "", fmt.Errorf("no package found in %s", dir)
+	}
+
+	links, err := loadLinks()
+	if err != nil {
+		return "", err
+	}
+
+	name := config.Package.Name
+	if register {
+		links[name] = dir
+	} else {
+		if _, ok := links[name]; !ok {
+			return "", fmt.Errorf("%s is not linked", name)
+		}
+		delete(links, name)
+	}
+
+	return name, saveLinks(links)
+}
+
+// installedPackage is one place a package is installed in the project
+type installedPackage struct {
+	Realm   Realm
+	Version string
+}
+
+// findInstalled returns every realm the locked versions of name are installed in
+func (ic *InstallationContext) findInstalled(name string) []installedPackage {
+	var found []installedPackage
+	for _, realm := range []Realm{RealmShared, RealmServer, RealmDev} {
+		for _, pkg := range ic.Lockfile[name] {
+			dir := filepath.Join(ic.getIndexDir(realm), packageIDFileName(name, pkg.Version))
+			if _, err := os.Stat(dir); err == nil {
+				found = append(found, installedPackage{Realm: realm, Version: pkg.Version})
+			}
+		}
+	}
+	return found
+}
+
+// linkTargets finds the installed copies bread link and unlink work on. A dependency alias or package
+// name of the manifest picks the locked version in that dependency's realm, any other package name
+// takes every installed version.
+func (ic *InstallationContext) linkTargets(arg string) (string, []installedPackage) {
+	name := ""
+	var targets []installedPackage
+	for _, r := range ic.dependenciesByRealm() {
+		for alias, dep := range r.deps {
+			dep, _ = applyOverride(NormalizeDependency(alias, dep), ic.overrides)
+			if !dep.IsRegistry() || (alias != arg && dep.Package != arg) {
+				continue
+			}
+			name = dep.Package
+
+			version := ic.lockedVersion(dep.Package, dep.Version)
+			dir := filepath.Join(ic.getIndexDir(r.realm), packageIDFileName(dep.Package, version))
+			if _, err := os.Stat(dir); version != "" && err == nil {
+				targets = append(targets, installedPackage{Realm: r.realm, Version: version})
+			}
+		}
+	}
+	if name != "" {
+		return name, targets
+	}
+	return arg, ic.findInstalled(arg)
+}
+
+// lockedVersion is the highest locked version of name within constraint
+func (ic *InstallationContext) lockedVersion(name, constraint string) string {
+	best := ""
+	for _, pkg := range ic.Lockfile[name] {
+		if MatchConstraint(pkg.Version, constraint) && (best == "" || semver.Compare("v"+pkg.Version, "v"+best) > 0) {
+			best = pkg.Version
+		}
+	}
+	return best
+}
+
+// refreshLinkFile rewrites the root link file of a package, if it has one in the realm
+func (ic *InstallationContext) refreshLinkFile(name, version string, realm Realm) error {
+	baseDir := ic.getRealmDir(realm)
+	if _, err := os.Stat(filepath.Join(baseDir, ic.linkFileName(name))); err != nil {
+		return nil
+	}
+	return ic.writeLinkFile(baseDir, name, version, realm)
+}
+
+// LinkPackage replaces the installed copy of a package with a symlink to the library registered with
+// bread link. arg is a dependency alias or package name. Returns the library directory.
+func (ic *InstallationContext) LinkPackage(arg string) (string, error) {
+	links, err := loadLinks()
+	if err != nil {
+		return "", err
+	}
+
+	name, installed := ic.linkTargets(arg)
+	source, ok := links[name]
+	if !ok {
+		return "", fmt.Errorf("%s is not registered, run bread link in the library first", name)
+	}
+	if len(installed) == 0 {
+		return "", fmt.Errorf("%s is not installed, add it as a dependency first", name)
+	}
+
+	for _, pkg := range installed {
+		target := filepath.Join(ic.getIndexDir(pkg.Realm), packageIDFileName(name, pkg.Version), getPackageName(name))
+		if err := linkLocalPackage(source, target); err != nil {
+			return "", err
+		}
+		if err := ic.refreshLinkFile(name, pkg.Version, pkg.Realm); err != nil {
+			return "", err
+		}
+	}
+
+	// remembered so bread install keeps the link instead of downloading over it
+	projectLinks, err := readLinks(ic.projectLinksFile())
+	if err != nil {
+		return "", err
+	}
+	projectLinks[name] = source
+	return source, writeLinks(ic.projectLinksFile(), projectLinks)
+}
+
+// UnlinkPackage puts the registry version back where LinkPackage symlinked a package, each copy at its
+// own locked version. arg is a dependency alias or package name.
+func (ic *InstallationContext) UnlinkPackage(arg string) error {
+	name, installed := ic.linkTargets(arg)
+	if len(installed) == 0 {
+		return fmt.Errorf("%s is not installed", name)
+	}
+
+	projectLinks, err := readLinks(ic.projectLinksFile())
+	if err != nil {
+		return err
+	}
+	delete(projectLinks, name)
+	if err := writeLinks(ic.projectLinksFile(), projectLinks); err != nil {
+		return err
+	}
+
+	for _, pkg := range installed {
+		version, api, err := ic.lockedRegistryPackage(name, pkg.Version)
+		if err != nil {
+			return err
+		}
+		if err := ic.downloadPackage(api, name, version, pkg.Realm); err != nil {
+			return err
+		}
+		if err := ic.refreshLinkFile(name, version, pkg.Realm); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keepLink links a package bread link replaced in this project again instead of downloading it.
+// Returns false when the package isn't linked or its library is gone.
+func (ic *InstallationContext) keepLink(name, version string, realm Realm) (bool, error) {
+	projectLinks, err := readLinks(ic.projectLinksFile())
+	if err != nil {
+		return false, err
+	}
+	source, ok := projectLinks[name]
+	if !ok {
+		return false, nil
+	}
+	if _, err := os.Stat(source); err != nil {
+		log.Warnf("%s was linked to %s, which no longer exists. Installing the registry version, run bread unlink %s to stop linking it", name, source, name)
+		return false, nil
+	}
+
+	target := filepath.Join(ic.getIndexDir(realm), packageIDFileName(name, version), getPackageName(name))
+	if err := linkLocalPackage(source, target); err != nil {
+		return false, err
+	}
+	log.Infof("%s Keeping %s linked to %s, run bread unlink %s to use the registry version", Info, name, source, name)
+	return true, nil
+}
@@ -0,0 +1,101 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLinkAndUnlinkPackage(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	w, _ := zw.Create("init.lua")
+	w.Write([]byte("return \"registry\"\n"))
+	zw.Close()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/package-contents/sleitnick/signal/2.0.0" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(archive.Bytes())
+	}))
+	defer server.Close()
+
+	registry := "https://github.com/me/test-index"
+	registryMu.Lock()
+	registryAPIs[registry] = server.URL
+	registryMu.Unlock()
+	t.Cleanup(func() {
+		registryMu.Lock()
+		delete(registryAPIs, registry)
+		registryMu.Unlock()
+	})
+
+	library := filepath.Join(t.TempDir(), "signal")
+	writeTree(t, library, map[string]string{
+		"bread.toml": "[package]\nname = \"sleitnick/signal\"\nversion = \"2.0.0\"\n",
+		"init.lua":   "return \"local\"\n",
+	})
+	if _, err := RegisterLink(library); err != nil {
+		t.Fatalf("RegisterLink failed: %v", err)
+	}
+
+	// 1.0.0 is locked first but Signal is installed at 2.0.0
+	dir := t.TempDir()
+	source := "registry+" + registry
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n\n[dependencies]\nSignal = { package = \"sleitnick/signal\", version = \"^2.0.0\", registry = \"" + registry + "\" }\n",
+		"bread.lock": "registry = \"test\"\n\n" +
+			"[[package]]\nname = \"sleitnick/signal\"\nversion = \"1.0.0\"\nsource = \"" + source + "\"\ndependencies = []\n\n" +
+			"[[package]]\nname = \"sleitnick/signal\"\nversion = \"2.0.0\"\nsource = \"" + source + "\"\ndependencies = []\n",
+		"Packages/_Index/sleitnick_signal@1.0.0/signal/init.lua": "return \"registry\"\n",
+		"Packages/_Index/sleitnick_signal@2.0.0/signal/init.lua": "return \"registry\"\n",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+
+	installed := filepath.Join(dir, "Packages", "_Index", "sleitnick_signal@2.0.0", "signal", "init.lua")
+	expectContent := func(path, want string) {
+		t.Helper()
+		if data, err := os.ReadFile(path); err != nil || string(data) != want {
+			t.Errorf("Expected %s to be %q, got %q %v", path, want, data, err)
+		}
+	}
+
+	if _, err := ic.LinkPackage("Signal"); err != nil {
+		t.Fatalf("LinkPackage failed: %v", err)
+	}
+	expectContent(installed, "return \"local\"\n")
+
+	// an install keeps the link
+	if err := ic.downloadPackage(server.URL, "sleitnick/signal", "2.0.0", RealmShared); err != nil {
+		t.Fatalf("downloadPackage failed: %v", err)
+	}
+	expectContent(installed, "return \"local\"\n")
+
+	if err := ic.UnlinkPackage("Signal"); err != nil {
+		t.Fatalf("UnlinkPackage failed: %v", err)
+	}
+	expectContent(installed, "return \"registry\"\n")
+	if info, err := os.Lstat(filepath.Dir(installed)); err != nil || info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("Expected the registry copy in place of the link")
+	}
+	expectContent(filepath.Join(library, "init.lua"), "return \"local\"\n")
+
+	projectLinks, err := readLinks(ic.projectLinksFile())
+	if err != nil || len(projectLinks) != 0 {
+		t.Errorf("Expected the link to be forgotten, got %v %v", projectLinks, err)
+	}
+}
@@ -3,20 +3,60 @@ package utils
 import (
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
+	"yoheiyayoi/bread/breadTypes"
 
 	"github.com/charmbracelet/log"
 )
 
-func (ic *InstallationContext) writeRootPackageLinks(realm Realm, dependencies map[string]string) error {
+func (ic *InstallationContext) writeRootPackageLinks(realm Realm, dependencies map[string]breadTypes.Dependency, session *installSession) error {
 	baseDir := ic.getRealmDir(realm)
 
 	if err := os.MkdirAll(baseDir, 0755); err != nil {
 		return err
 	}
 
-	for depName, versionSpec := range dependencies {
-		pkgName, version := ParsePackageSpec(depName, versionSpec)
+	for depName, dep := range dependencies {
+		dep, _ = applyOverride(NormalizeDependency(depName, dep), ic.overrides)
+		dep = ic.resolveWorkspaceDependency(dep)
+		pkgName, version := dep.Package, dep.Version
+		switch {
+		case dep.IsPath():
+			pkg, err := ic.readLocalPackage(dep)
+			if err != nil {
+				return err
+			}
+			pkgName, version = pkg.Name, pkg.Version
+		case dep.IsGit():
+			pkg, ok := ic.gitPackages.Load(dep.Source())
+			if !ok {
+				if dep.Optional {
+					continue
+				}
+				return fmt.Errorf("git dependency %s was not installed", depName)
+			}
+			pkgName, version = pkg.(*gitPackage).Name, pkg.(*gitPackage).Version
+		default:
+			// dep.Version is the constraint, the folder and link are named after the version installed
+			resolved, ok := session.resolvedVersion(pkgName, dep.Version)
+			if !ok {
+				if dep.Optional {
+					continue
+				}
+				return fmt.Errorf("%s@%s was not installed", pkgName, dep.Version)
+			}
+			version = resolved
+		}
+
+		// Optional dependencies that failed to install get no link file
+		if dep.Optional {
+			if _, err := os.Stat(filepath.Join(ic.getIndexDir(realm), packageIDFileName(pkgName, version))); err != nil {
+				continue
+			}
+		}
+
 		if err := ic.writeLinkFile(baseDir, pkgName, version, realm); err != nil {
 			return err
 		}
@@ -25,20 +65,54 @@ func (ic *InstallationContext) writeRootPackageLinks(realm Realm, dependencies m
 	return nil
 }
 
+// Runtimes link files are written for, [bread] target
+const (
+	TargetRoblox = "roblox"
+	TargetLuau   = "luau"
+	TargetLune   = "lune"
+)
+
+// isRoblox reports whether link files run inside a Roblox DataModel, the default
+func (ic *InstallationContext) isRoblox() bool {
+	target := ic.Manifest.BreadConfig.Target
+	return target == "" || target == TargetRoblox
+}
+
+// linkFileName is Name.lua for Roblox, Name.luau for standalone Luau
+func (ic *InstallationContext) linkFileName(pkgName string) string {
+	if ic.isRoblox() {
+		return getPackageName(pkgName) + ".lua"
+	}
+	return getPackageName(pkgName) + ".luau"
+}
+
 func (ic *InstallationContext) writeLinkFile(baseDir, pkgName, version string, realm Realm) error {
-	shortName := getPackageName(pkgName)
-	linkPath := filepath.Join(baseDir, shortName+".lua")
+	linkPath := filepath.Join(baseDir, ic.linkFileName(pkgName))
 	content := ic.linkRootSameIndex(pkgName, version, realm)
-	return os.WriteFile(linkPath, []byte(content), 0644)
+	if err := os.WriteFile(linkPath, []byte(content), 0644); err != nil {
+		return err
+	}
+
+	// A link file left from another target would shadow this one
+	for _, ext := range []string{".lua", ".luau"} {
+		if stale := filepath.Join(baseDir, getPackageName(pkgName)+ext); stale != linkPath {
+			os.Remove(stale)
+		}
+	}
+	return nil
 }
 
 func (ic *InstallationContext) linkRootSameIndex(name, version string, realm Realm) string {
 	fullName := packageIDFileName(name, version)
 	shortName := getPackageName(name)
+	packageDir := filepath.Join(ic.getIndexDir(realm), fullName, shortName)
+
 	requirePath := fmt.Sprintf("require(script.Parent.%s[\"%s\"][\"%s\"])", IndexDirName, fullName, shortName)
+	if !ic.isRoblox() {
+		requirePath = fmt.Sprintf("require(%q)", stringRequirePath(packageDir, fullName, shortName))
+	}
 
 	// Try to extract types from the package
-	packageDir := filepath.Join(ic.getIndexDir(realm), fullName, shortName)
 	typeExtractor := NewTypeExtractor()
 	types, err := typeExtractor.ExtractTypesFromPackage(packageDir, shortName)
 
@@ -53,3 +127,29 @@ func (ic *InstallationContext) linkRootSameIndex(name, version string, realm Rea
 	// Generate link file with type re-exports
 	return typeExtractor.GenerateLinkFileWithTypes(requirePath, types, "_Package", fullName)
 }
+
+// stringRequirePath is the require-by-string path from a link file to the package's main module,
+// e.g. ./_Index/scope_name@1.0.0/name/src for a package whose entry is src/init.luau
+func stringRequirePath(packageDir, fullName, shortName string) string {
+	base := "./" + path.Join(IndexDirName, fullName, shortName)
+
+	entry := findPackageEntry(packageDir, shortName)
+	if entry == "" {
+		return base
+	}
+	rel, err := filepath.Rel(packageDir, entry)
+	if err != nil {
+		return base
+	}
+
+	rel = filepath.ToSlash(rel)
+	file := path.Base(rel)
+	if strings.HasPrefix(file, "init.") {
+		// Requiring a folder loads its init file
+		if dir := path.Dir(rel); dir != "." {
+			return base + "/" + dir
+		}
+		return base
+	}
+	return base + "/" + strings.TrimSuffix(rel, path.Ext(rel))
+}
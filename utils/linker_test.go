@@ -0,0 +1,77 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+func TestLinkFileForStandaloneLuau(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/tools\"\nversion = \"0.1.0\"\n\n[bread]\ntarget = \"lune\"\n",
+		"Packages/_Index/sleitnick_signal@2.0.0/signal/src/init.luau": "export type Signal = {}\nreturn {}\n",
+		"Packages/signal.lua": "-- left from a roblox install\n",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	if err := ic.writeLinkFile(ic.SharedDir, "sleitnick/signal", "2.0.0", RealmShared); err != nil {
+		t.Fatalf("writeLinkFile failed: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(dir, "Packages", "signal.luau"))
+	if err != nil {
+		t.Fatalf("Expected a .luau link file: %v", err)
+	}
+	if !strings.Contains(string(content), `require("./_Index/sleitnick_signal@2.0.0/signal/src")`) {
+		t.Errorf("Expected a string require, got:\n%s", content)
+	}
+	if !strings.Contains(string(content), "export type Signal = _Package.Signal") {
+		t.Errorf("Expected the types to be re-exported, got:\n%s", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Packages", "signal.lua")); !os.IsNotExist(err) {
+		t.Errorf("Expected the old .lua link file to be removed")
+	}
+}
+
+func TestRootLinksUseResolvedVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n",
+		"Packages/_Index/sleitnick_signal@2.1.0/signal/init.lua": "return {}\n",
+		"Packages/_Index/evaera_promise@4.0.0/promise/init.lua":  "return {}\n",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	session := newInstallSession(0)
+	session.storePackage("sleitnick/signal", "2.0.0", "", nil, nil)
+	session.storePackage("sleitnick/signal", "2.1.0", "", nil, nil)
+	session.storePackage("evaera/promise", "4.0.0", "", nil, nil)
+
+	deps := map[string]breadTypes.Dependency{
+		"Signal":  {Spec: "sleitnick/signal@^2.0.0"},
+		"Promise": {Spec: "evaera/promise@^4.0.0", Optional: true},
+		"Maid":    {Spec: "quenty/maid@^1.0.0", Optional: true},
+	}
+	if err := ic.writeRootPackageLinks(RealmShared, deps, session); err != nil {
+		t.Fatalf("writeRootPackageLinks failed: %v", err)
+	}
+
+	for name, id := range map[string]string{"signal": "sleitnick_signal@2.1.0", "promise": "evaera_promise@4.0.0"} {
+		content, err := os.ReadFile(filepath.Join(dir, "Packages", name+".lua"))
+		if err != nil || !strings.Contains(string(content), id) {
+			t.Errorf("Expected %s to link %s, got %q %v", name, id, content, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Packages", "maid.lua")); !os.IsNotExist(err) {
+		t.Errorf("Expected no link for an optional dependency that wasn't installed")
+	}
+}
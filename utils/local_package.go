@@ -0,0 +1,161 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+// localPackage is a package read from a directory on disk instead of the registry
+type localPackage struct {
+	Name         string
+	Version      string
+	Dir          string
+	Dependencies map[string]breadTypes.Dependency
+}
+
+// readLocalPackage loads the manifest of a path dependency.
+// Path dependencies of the package are rebased so they stay relative to the project.
+func (ic *InstallationContext) readLocalPackage(dep breadTypes.Dependency) (*localPackage, error) {
+	dir, err := filepath.Abs(filepath.Join(ic.ProjectPath, dep.Path))
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := readPackageManifest(dir)
+	if err != nil {
+		return nil, err
+	}
+	if config == nil {
+		return nil, fmt.Errorf("no bread.toml or wally.toml found in %s", dep.Path)
+	}
+	if config.Package.Name == "" || config.Package.Version == "" {
+		return nil, fmt.Errorf("package in %s must have a name and version", dep.Path)
+	}
+
+	deps := make(map[string]breadTypes.Dependency, len(config.Dependencies))
+	for name, d := range config.Dependencies {
+		if d.IsPath() && !filepath.IsAbs(d.Path) {
+			rel, err := filepath.Rel(ic.ProjectPath, filepath.Join(dir, d.Path))
+			if err != nil {
+				return nil, err
+			}
+			d.Path = filepath.ToSlash(rel)
+		}
+		deps[name] = d
+	}
+
+	return &localPackage{
+		Name:         config.Package.Name,
+		Version:      config.Package.Version,
+		Dir:          dir,
+		Dependencies: deps,
+	}, nil
+}
+
+// lockSpec is how a dependency is written in bread.lock.
+// Paths are made relative to the lockfile so workspace members agree on them.
+func (ic *InstallationContext) lockSpec(dep breadTypes.Dependency) string {
+	if !dep.IsPath() || filepath.IsAbs(dep.Path) {
+		return dep.String()
+	}
+
+	rel, err := filepath.Rel(ic.lockRoot(), filepath.Join(ic.ProjectPath, dep.Path))
+	if err != nil {
+		return dep.String()
+	}
+	return "path+" + filepath.ToSlash(rel)
+}
+
+func (ic *InstallationContext) installLocalPackage(dep breadTypes.Dependency, realm Realm, session *installSession) {
+	pkg, err := ic.readLocalPackage(dep)
+	if err != nil {
+		session.fail(dep, err)
+		return
+	}
+
+	pkgID := fmt.Sprintf("%s:%s@%s", ic.getIndexDir(realm), pkg.Name, pkg.Version)
+	if _, exists := session.visited.LoadOrStore(pkgID, true); exists {
+		return
+	}
+
+	targetDir := filepath.Join(ic.getIndexDir(realm), packageIDFileName(pkg.Name, pkg.Version), getPackageName(pkg.Name))
+	if err := linkLocalPackage(pkg.Dir, targetDir); err != nil {
+		session.fail(dep, fmt.Errorf("failed to link %s: %w", dep.Path, err))
+		return
+	}
+
+	n := session.successCount.Add(1)
+	session.msgChan <- pkgInstalledMsg{
+		name:    fmt.Sprintf("%s %s", pkg.Name, versionStyle.Render(dep.Path)),
+		current: int(n),
+		total:   int(session.total.Load()),
+	}
+
+	session.storePackage(pkg.Name, pkg.Version, ic.lockSpec(dep), ic.sortedDeps(pkg.Dependencies), ic.overriddenAliases(pkg.Dependencies))
+
+	session.total.Add(int32(len(pkg.Dependencies)))
+	for depName, d := range pkg.Dependencies {
+		ic.installPackage(depName, d, realm, session)
+	}
+}
+
+// linkLocalPackage points dest at src so edits show up without reinstalling.
+// Symlinks need extra privileges on Windows, so fall back to a fresh copy there.
+func linkLocalPackage(src, dest string) error {
+	if err := os.RemoveAll(dest); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return err
+	}
+
+	if err := os.Symlink(src, dest); err == nil {
+		return nil
+	}
+
+	return copyDir(src, dest)
+}
+
+func copyDir(src, dest string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dest, rel)
+
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return filepath.SkipDir
+			}
+			return os.MkdirAll(target, 0755)
+		}
+
+		return copyFile(path, target)
+	})
+}
+
+func copyFile(src, dest string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	_, err = io.Copy(out, in)
+	return err
+}
@@ -0,0 +1,111 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+func TestInstallLocalPackage(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"game/bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n\n[dependencies]\nLib = { path = \"../libs/lib\" }\n",
+		// Util's path is relative to lib, not to the game
+		"libs/lib/bread.toml":  "[package]\nname = \"me/lib\"\nversion = \"1.0.0\"\n\n[dependencies]\nUtil = { path = \"../util\" }\n",
+		"libs/lib/init.lua":    "return {}\n",
+		"libs/util/wally.toml": "[package]\nname = \"me/util\"\nversion = \"0.2.0\"\nrealm = \"shared\"\n",
+		"libs/util/init.lua":   "return {}\n",
+	})
+
+	ic := NewInstaller(filepath.Join(root, "game"), nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+
+	session := newInstallSession(1)
+	ic.installPackage("Lib", ic.Manifest.Dependencies["Lib"], RealmShared, session)
+	session.wg.Wait()
+	if err := session.collectErrors(); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+
+	index := ic.getIndexDir(RealmShared)
+	lib := filepath.Join(index, "me_lib@1.0.0", "lib")
+	if info, err := os.Lstat(lib); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("Expected lib to be symlinked, got %v %v", info, err)
+	} else if target, _ := os.Readlink(lib); target != filepath.Join(root, "libs", "lib") {
+		t.Errorf("Expected lib to point at the library, got %s", target)
+	}
+	if _, err := os.Stat(filepath.Join(index, "me_util@0.2.0", "util", "init.lua")); err != nil {
+		t.Errorf("Expected the transitive path dependency to be installed: %v", err)
+	}
+
+	locked := make(map[string]*breadTypes.LockedPackage)
+	for _, pkg := range ic.collectLockedPackages(session) {
+		locked[pkg.Name] = &pkg
+	}
+	if pkg := locked["me/lib"]; pkg == nil || pkg.Source != "path+../libs/lib" ||
+		!slices.EqualFunc(pkg.Dependencies, [][]string{{"Util", "path+../libs/util"}}, slices.Equal) {
+		t.Errorf("Unexpected lock entry for me/lib: %+v", pkg)
+	}
+	if pkg := locked["me/util"]; pkg == nil || pkg.Source != "path+../libs/util" {
+		t.Errorf("Unexpected lock entry for me/util: %+v", pkg)
+	}
+}
+
+func TestLockSpecIsRelativeToLockRoot(t *testing.T) {
+	root := t.TempDir()
+	ic := &InstallationContext{ProjectPath: filepath.Join(root, "packages", "game"), WorkspaceRoot: root}
+
+	tests := []struct {
+		dep      breadTypes.Dependency
+		expected string
+	}{
+		{breadTypes.Dependency{Path: "../lib"}, "path+packages/lib"},
+		{breadTypes.Dependency{Path: "../../vendor/util"}, "path+vendor/util"},
+		{breadTypes.Dependency{Spec: "sleitnick/signal@^2.0.0"}, "sleitnick/signal@^2.0.0"},
+	}
+	for _, tt := range tests {
+		if got := ic.lockSpec(tt.dep); got != tt.expected {
+			t.Errorf("lockSpec(%+v) = %q, expected %q", tt.dep, got, tt.expected)
+		}
+	}
+
+	// without a workspace paths stay relative to the project
+	ic.WorkspaceRoot = ""
+	if got := ic.lockSpec(breadTypes.Dependency{Path: "../lib"}); got != "path+../lib" {
+		t.Errorf("Expected path+../lib, got %q", got)
+	}
+}
+
+func TestCopyDirFallback(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "lib")
+	writeTree(t, src, map[string]string{
+		"init.lua":     "return {}\n",
+		"src/util.lua": "return 1\n",
+		".git/HEAD":    "ref: refs/heads/main\n",
+	})
+
+	dest := filepath.Join(dir, "Packages", "lib")
+	if err := os.MkdirAll(dest, 0755); err != nil {
+		t.Fatalf("Failed to create dest: %v", err)
+	}
+	if err := copyDir(src, dest); err != nil {
+		t.Fatalf("copyDir failed: %v", err)
+	}
+
+	for _, name := range []string{"init.lua", "src/util.lua"} {
+		if _, err := os.Stat(filepath.Join(dest, name)); err != nil {
+			t.Errorf("Expected %s to be copied", name)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dest, ".git")); err == nil {
+		t.Errorf("Expected .git to be left out")
+	}
+	if info, _ := os.Lstat(dest); info.Mode()&os.ModeSymlink != 0 {
+		t.Errorf("Expected a copy, not a link")
+	}
+}
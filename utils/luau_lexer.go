@@ -0,0 +1,217 @@
+package utils
+
+import "strings"
+
+type luauTokenKind int
+
+const (
+	luauName luauTokenKind = iota
+	luauString
+	luauNumber
+	luauSymbol
+)
+
+// luauToken is one token of a Luau source, comments and whitespace are dropped
+type luauToken struct {
+	kind  luauTokenKind
+	text  string // source text, strings keep their quotes
+	start int    // byte offsets into the source
+	end   int
+}
+
+func (t luauToken) is(text string) bool {
+	return t.kind != luauString && t.text == text
+}
+
+// luauLexer splits Luau source into tokens. It only has to be good enough to find
+// declarations, so anything it doesn't know becomes a one character symbol.
+type luauLexer struct {
+	src string
+	pos int
+}
+
+// lexLuau tokenizes src, unterminated strings and comments run to the end of the source
+func lexLuau(src string) []luauToken {
+	l := &luauLexer{src: src}
+	var tokens []luauToken
+	for {
+		tok, ok := l.next()
+		if !ok {
+			return tokens
+		}
+		tokens = append(tokens, tok)
+	}
+}
+
+func (l *luauLexer) next() (luauToken, bool) {
+	l.skipSpace()
+	if l.pos >= len(l.src) {
+		return luauToken{}, false
+	}
+
+	start := l.pos
+	c := l.src[l.pos]
+	kind := luauSymbol
+
+	switch {
+	case isNameStart(c):
+		for l.pos < len(l.src) && isNameChar(l.src[l.pos]) {
+			l.pos++
+		}
+		kind = luauName
+
+	case isDigit(c) || (c == '.' && l.pos+1 < len(l.src) && isDigit(l.src[l.pos+1])):
+		l.scanNumber(start)
+		kind = luauNumber
+
+	case c == '"' || c == '\'':
+		l.scanQuoted(c)
+		kind = luauString
+
+	case c == '`':
+		l.scanInterpolated()
+		kind = luauString
+
+	case c == '[' && l.longBracketLevel() >= 0:
+		l.scanLongBracket(l.longBracketLevel())
+		kind = luauString
+
+	default:
+		l.pos++
+		for _, symbol := range []string{"...", "..", "->", "::"} {
+			if strings.HasPrefix(l.src[start:], symbol) {
+				l.pos = start + len(symbol)
+				break
+			}
+		}
+	}
+
+	return luauToken{kind: kind, text: l.src[start:l.pos], start: start, end: l.pos}, true
+}
+
+// skipSpace skips whitespace and -- comments, including --[[ ]] and --[==[ ]==]
+func (l *luauLexer) skipSpace() {
+	for l.pos < len(l.src) {
+		switch {
+		case strings.ContainsRune(" \t\r\n\f\v", rune(l.src[l.pos])):
+			l.pos++
+		case strings.HasPrefix(l.src[l.pos:], "--"):
+			l.pos += 2
+			if level := l.longBracketLevel(); level >= 0 {
+				l.scanLongBracket(level)
+				continue
+			}
+			if end := strings.IndexByte(l.src[l.pos:], '\n'); end >= 0 {
+				l.pos += end + 1
+			} else {
+				l.pos = len(l.src)
+			}
+		default:
+			return
+		}
+	}
+}
+
+// longBracketLevel returns the number of = in a [[ or [==[ at pos, -1 if there isn't one
+func (l *luauLexer) longBracketLevel() int {
+	if l.pos >= len(l.src) || l.src[l.pos] != '[' {
+		return -1
+	}
+	i := l.pos + 1
+	for i < len(l.src) && l.src[i] == '=' {
+		i++
+	}
+	if i < len(l.src) && l.src[i] == '[' {
+		return i - l.pos - 1
+	}
+	return -1
+}
+
+func (l *luauLexer) scanLongBracket(level int) {
+	l.pos += level + 2
+	closing := "]" + strings.Repeat("=", level) + "]"
+	if end := strings.Index(l.src[l.pos:], closing); end >= 0 {
+		l.pos += end + len(closing)
+	} else {
+		l.pos = len(l.src)
+	}
+}
+
+func (l *luauLexer) scanQuoted(quote byte) {
+	l.pos++
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+			continue
+		case quote:
+			l.pos++
+			return
+		case '\n':
+			return // unterminated, don't swallow the rest of the file
+		}
+		l.pos++
+	}
+	l.pos = len(l.src)
+}
+
+// scanInterpolated skips `text {expr} text`, the expressions can hold strings and braces of their own
+func (l *luauLexer) scanInterpolated() {
+	l.pos++
+	for l.pos < len(l.src) {
+		switch l.src[l.pos] {
+		case '\\':
+			l.pos += 2
+			continue
+		case '`':
+			l.pos++
+			return
+		case '{':
+			l.pos++
+			depth := 1
+			for depth > 0 {
+				tok, ok := l.next()
+				if !ok {
+					return
+				}
+				switch {
+				case tok.is("{"):
+					depth++
+				case tok.is("}"):
+					depth--
+				}
+			}
+			continue
+		}
+		l.pos++
+	}
+	l.pos = len(l.src)
+}
+
+// scanNumber covers 123, 1_000, 0xFF, 0b101, 1.5e-3
+func (l *luauLexer) scanNumber(start int) {
+	hex := strings.HasPrefix(l.src[start:], "0x") || strings.HasPrefix(l.src[start:], "0X")
+	for l.pos < len(l.src) {
+		c := l.src[l.pos]
+		switch {
+		case isNameChar(c) || c == '.':
+			l.pos++
+		case (c == '+' || c == '-') && !hex && strings.ContainsRune("eE", rune(l.src[l.pos-1])):
+			l.pos++
+		default:
+			return
+		}
+	}
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isNameChar(c byte) bool {
+	return isNameStart(c) || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
@@ -0,0 +1,88 @@
+package utils
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// LuaurcFile holds the require aliases for the Luau CLI and luau-lsp
+const LuaurcFile = ".luaurc"
+
+// WriteLuaurc adds an alias per package folder to .luaurc, and one per installed package
+// if [bread] luaurc_packages is set. Other keys and aliases are kept.
+func (ic *InstallationContext) WriteLuaurc() (string, error) {
+	path := filepath.Join(ic.ProjectPath, LuaurcFile)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		data = []byte("{}\n")
+	} else if err != nil {
+		return path, err
+	}
+
+	aliases := ic.luauAliases()
+	names := make([]string, 0, len(aliases))
+	for name := range aliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	editor := newJSONEditor(data)
+	for _, name := range names {
+		if err := editor.set([]string{"aliases", name}, jsonQuote(aliases[name])); err != nil {
+			return path, err
+		}
+	}
+
+	if bytes.Equal(editor.src, data) {
+		return path, nil
+	}
+	return path, os.WriteFile(path, editor.src, 0644)
+}
+
+// luauAliases returns alias -> path relative to the project, e.g. Packages -> ./Packages
+func (ic *InstallationContext) luauAliases() map[string]string {
+	aliases := make(map[string]string)
+
+	relative := func(path string) string {
+		rel, err := filepath.Rel(ic.ProjectPath, path)
+		if err != nil {
+			return filepath.ToSlash(path)
+		}
+		return "./" + filepath.ToSlash(rel)
+	}
+
+	realms := ic.realmsInUse()
+	for _, realm := range realms {
+		dir := ic.getRealmDir(realm)
+		aliases[filepath.Base(dir)] = relative(dir)
+	}
+
+	if !ic.Manifest.BreadConfig.LuaurcPackages {
+		return aliases
+	}
+
+	// Link files, the first realm wins when two have the same package
+	for _, realm := range realms {
+		dir := ic.getRealmDir(realm)
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			ext := filepath.Ext(entry.Name())
+			if entry.IsDir() || (ext != ".lua" && ext != ".luau") {
+				continue
+			}
+			name := strings.TrimSuffix(entry.Name(), ext)
+			if _, exists := aliases[name]; !exists {
+				aliases[name] = relative(filepath.Join(dir, name))
+			}
+		}
+	}
+
+	return aliases
+}
@@ -0,0 +1,30 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteLuaurcMergesAliases(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bread.toml":          "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n\n[bread]\nluaurc_packages = true\n",
+		"Packages/Signal.lua": "",
+		".luaurc":             "{\n\t// keep me\n\t\"languageMode\": \"strict\",\n\t\"aliases\": {\n\t\t\"Packages\": \"./old\",\n\t\t\"Tools\": \"./tools\"\n\t}\n}\n",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	if _, err := ic.WriteLuaurc(); err != nil {
+		t.Fatalf("WriteLuaurc failed: %v", err)
+	}
+
+	want := "{\n\t// keep me\n\t\"languageMode\": \"strict\",\n\t\"aliases\": {\n\t\t\"Packages\": \"./Packages\",\n\t\t\"Tools\": \"./tools\",\n\t\t\"Signal\": \"./Packages/Signal\"\n\t}\n}\n"
+	got, _ := os.ReadFile(filepath.Join(dir, LuaurcFile))
+	if string(got) != want {
+		t.Errorf("Unexpected .luaurc.\nGot:\n%s\nExpected:\n%s", got, want)
+	}
+}
@@ -0,0 +1,391 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+// ManifestEditor changes single keys of bread.toml in place.
+// Everything it doesn't touch (comments, order, blank lines, keys bread doesn't model) stays as written.
+type ManifestEditor struct {
+	path    string
+	lines   []string
+	newline string
+}
+
+type tomlEntryKind int
+
+const (
+	tomlOther tomlEntryKind = iota
+	tomlHeader
+	tomlKeyValue
+)
+
+// tomlEntry is a header, key/value or other (comment, blank) line range of the document
+type tomlEntry struct {
+	kind  tomlEntryKind
+	table string // table the entry belongs to, or the name of the header
+	key   string // dotted key with quotes removed
+	start int    // first line
+	end   int    // one past the last line, values can span lines
+}
+
+var bareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// OpenManifest reads the manifest at path for editing
+func OpenManifest(path string) (*ManifestEditor, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	content := string(data)
+	newline := "\n"
+	if strings.Contains(content, "\r\n") {
+		newline = "\r\n"
+		content = strings.ReplaceAll(content, "\r\n", "\n")
+	}
+
+	return &ManifestEditor{
+		path:    path,
+		lines:   strings.Split(content, "\n"),
+		newline: newline,
+	}, nil
+}
+
+// Save writes the edited manifest back
+func (m *ManifestEditor) Save() error {
+	return os.WriteFile(m.path, []byte(m.String()), 0644)
+}
+
+func (m *ManifestEditor) String() string {
+	return strings.Join(m.lines, m.newline)
+}
+
+// SetDependency adds or replaces a dependency, written in the form dep was read in
+func (m *ManifestEditor) SetDependency(table, alias string, dep breadTypes.Dependency) error {
+	value, err := dep.MarshalTOML()
+	if err != nil {
+		return err
+	}
+	return m.setRaw(table, alias, string(value))
+}
+
+// RemoveDependency deletes a dependency, reports whether it was there
+func (m *ManifestEditor) RemoveDependency(table, alias string) bool {
+	return m.Remove(table, alias)
+}
+
+// SetString sets a string key, adding the key or table if needed
+func (m *ManifestEditor) SetString(table, key, value string) error {
+	return m.setRaw(table, key, breadTypes.QuoteTOML(value))
+}
+
+// Remove deletes a key, or a [table.key] sub-table, reports whether it was there
+func (m *ManifestEditor) Remove(table, key string) bool {
+	found := false
+	for {
+		entries := m.scan()
+		start, end, ok := m.findKey(entries, table, key)
+		if !ok {
+			return found
+		}
+		m.lines = append(m.lines[:start], m.lines[end:]...)
+		found = true
+	}
+}
+
+func (m *ManifestEditor) setRaw(table, key, value string) error {
+	entries := m.scan()
+
+	if start, end, ok := m.findKeyValue(entries, table, key); ok {
+		line := m.lines[start]
+		eq := keyValueSplit(line)
+		if eq < 0 {
+			return fmt.Errorf("can't parse line %d of %s", start+1, m.path)
+		}
+
+		prefix := strings.TrimRight(line[:eq+1], " \t") + " "
+		comment := ""
+		if end-start == 1 {
+			if idx := trailingComment(line[eq+1:]); idx >= 0 {
+				comment = " " + strings.TrimSpace(line[eq+1+idx:])
+			}
+		}
+
+		replaced := []string{prefix + value + comment}
+		m.lines = append(m.lines[:start], append(replaced, m.lines[end:]...)...)
+		return nil
+	}
+
+	// A [table.key] sub-table is replaced by an inline entry
+	if m.Remove(table, key) {
+		entries = m.scan()
+	}
+
+	headerIdx := -1
+	for i, e := range entries {
+		if e.kind == tomlHeader && e.table == table {
+			headerIdx = i
+			break
+		}
+	}
+
+	if headerIdx < 0 {
+		// Trim trailing blank lines and add the table at the end
+		for len(m.lines) > 0 && strings.TrimSpace(m.lines[len(m.lines)-1]) == "" {
+			m.lines = m.lines[:len(m.lines)-1]
+		}
+		if len(m.lines) > 0 {
+			m.lines = append(m.lines, "")
+		}
+		m.lines = append(m.lines, "["+table+"]", formatKey(key)+" = "+value, "")
+		return nil
+	}
+
+	// Insert after the last key of the table, matching its indentation
+	insertAt := entries[headerIdx].end
+	indent := ""
+	for _, e := range entries[headerIdx+1:] {
+		if e.kind == tomlHeader {
+			break
+		}
+		if e.kind == tomlKeyValue {
+			if indent == "" && insertAt == entries[headerIdx].end {
+				line := m.lines[e.start]
+				indent = line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+			}
+			insertAt = e.end
+		}
+	}
+
+	line := indent + formatKey(key) + " = " + value
+	m.lines = append(m.lines[:insertAt], append([]string{line}, m.lines[insertAt:]...)...)
+	return nil
+}
+
+// findKey finds a key/value or a [table.key] sub-table and returns its line range
+func (m *ManifestEditor) findKey(entries []tomlEntry, table, key string) (int, int, bool) {
+	if start, end, ok := m.findKeyValue(entries, table, key); ok {
+		return start, end, true
+	}
+
+	sub := table + "." + key
+	for i, e := range entries {
+		if e.kind != tomlHeader || (e.table != sub && !strings.HasPrefix(e.table, sub+".")) {
+			continue
+		}
+
+		end := len(m.lines)
+		for _, next := range entries[i+1:] {
+			if next.kind == tomlHeader {
+				end = next.start
+				break
+			}
+		}
+		return e.start, end, true
+	}
+
+	return 0, 0, false
+}
+
+// findKeyValue finds key in table, or a top level dotted "table.key"
+func (m *ManifestEditor) findKeyValue(entries []tomlEntry, table, key string) (int, int, bool) {
+	for _, e := range entries {
+		if e.kind != tomlKeyValue {
+			continue
+		}
+		if (e.table == table && e.key == key) || (e.table == "" && e.key == table+"."+key) {
+			return e.start, e.end, true
+		}
+	}
+	return 0, 0, false
+}
+
+// scan splits the document into headers, key/values and everything else
+func (m *ManifestEditor) scan() []tomlEntry {
+	var entries []tomlEntry
+	table := ""
+
+	for i := 0; i < len(m.lines); i++ {
+		trimmed := strings.TrimSpace(m.lines[i])
+
+		switch {
+		case trimmed == "" || strings.HasPrefix(trimmed, "#"):
+			entries = append(entries, tomlEntry{kind: tomlOther, table: table, start: i, end: i + 1})
+
+		case strings.HasPrefix(trimmed, "["):
+			name := trimmed
+			if idx := trailingComment(name); idx >= 0 {
+				name = strings.TrimSpace(name[:idx])
+			}
+			isArray := strings.HasPrefix(name, "[[")
+			name = strings.Trim(name, "[]")
+			table = normalizeKey(name)
+			if isArray {
+				// Entries of an array of tables are never edited, keep them apart
+				table = "[[" + table + "]]"
+			}
+			entries = append(entries, tomlEntry{kind: tomlHeader, table: table, start: i, end: i + 1})
+
+		default:
+			eq := keyValueSplit(m.lines[i])
+			if eq < 0 {
+				entries = append(entries, tomlEntry{kind: tomlOther, table: table, start: i, end: i + 1})
+				continue
+			}
+
+			end := valueEnd(m.lines, i, eq+1)
+			entries = append(entries, tomlEntry{
+				kind:  tomlKeyValue,
+				table: table,
+				key:   normalizeKey(m.lines[i][:eq]),
+				start: i,
+				end:   end,
+			})
+			i = end - 1
+		}
+	}
+
+	return entries
+}
+
+// keyValueSplit returns the index of the "=" between key and value, -1 if the line isn't a key/value
+func keyValueSplit(line string) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '=':
+			return i
+		case c == '#':
+			return -1
+		}
+	}
+	return -1
+}
+
+// valueEnd finds the line after a value starting at lines[start][col:], following multi-line arrays and strings
+func valueEnd(lines []string, start, col int) int {
+	depth := 0
+	var quote string
+
+	for i := start; i < len(lines); i++ {
+		line := lines[i]
+		j := 0
+		if i == start {
+			j = col
+		}
+
+	scan:
+		for ; j < len(line); j++ {
+			rest := line[j:]
+			switch {
+			case quote != "":
+				if quote == `"` || quote == `"""` {
+					if line[j] == '\\' {
+						j++
+						continue
+					}
+				}
+				if strings.HasPrefix(rest, quote) {
+					j += len(quote) - 1
+					quote = ""
+				}
+			case strings.HasPrefix(rest, `"""`) || strings.HasPrefix(rest, `'''`):
+				quote = rest[:3]
+				j += 2
+			case line[j] == '"' || line[j] == '\'':
+				quote = string(line[j])
+			case line[j] == '[' || line[j] == '{':
+				depth++
+			case line[j] == ']' || line[j] == '}':
+				depth--
+			case line[j] == '#':
+				break scan
+			}
+		}
+
+		// Single quoted strings can't span lines, only the triple quoted ones can
+		if quote == `"` || quote == "'" {
+			quote = ""
+		}
+		if depth <= 0 && quote == "" {
+			return i + 1
+		}
+	}
+
+	return len(lines)
+}
+
+// trailingComment returns the index of a "#" comment outside strings, -1 if there is none
+func trailingComment(s string) int {
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return i
+		}
+	}
+	return -1
+}
+
+// normalizeKey turns ` "a" . b ` into "a.b"
+func normalizeKey(key string) string {
+	var parts []string
+	var current strings.Builder
+	var quote byte
+
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' && i+1 < len(key) {
+				i++
+				current.WriteByte(key[i])
+			} else if c == quote {
+				quote = 0
+			} else {
+				current.WriteByte(c)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '.':
+			parts = append(parts, current.String())
+			current.Reset()
+		case c == ' ' || c == '\t':
+		default:
+			current.WriteByte(c)
+		}
+	}
+
+	return strings.Join(append(parts, current.String()), ".")
+}
+
+// formatKey quotes a key unless it's a bare key
+func formatKey(key string) string {
+	if bareKeyPattern.MatchString(key) {
+		return key
+	}
+	return breadTypes.QuoteTOML(key)
+}
@@ -0,0 +1,165 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+
+	"github.com/BurntSushi/toml"
+)
+
+const editorManifest = `# My game
+[package]
+name = "me/game"
+version = "0.1.0"
+
+[place]
+shared-packages = "game.ReplicatedStorage.Packages"
+
+[dependencies]
+  # networking
+  Net = "sleitnick/net@0.2.0" # pinned
+  Signal = { package = "sleitnick/signal", version = "^2.0.0" }
+  Tools = [
+    "a",
+    "b",
+  ]
+
+[dev-dependencies.TestEZ]
+package = "roblox/testez"
+version = "0.4.1"
+
+[server-dependencies]
+`
+
+func openTestManifest(t *testing.T, content string) (*ManifestEditor, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bread.toml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	editor, err := OpenManifest(path)
+	if err != nil {
+		t.Fatalf("OpenManifest failed: %v", err)
+	}
+	return editor, path
+}
+
+func TestManifestEditorAddAndReplace(t *testing.T) {
+	editor, _ := openTestManifest(t, editorManifest)
+
+	if err := editor.SetDependency("dependencies", "Promise", breadTypes.Dependency{Spec: "evaera/promise@4.0.0"}); err != nil {
+		t.Fatalf("SetDependency failed: %v", err)
+	}
+	if err := editor.SetDependency("dependencies", "Net", breadTypes.Dependency{Spec: "sleitnick/net@0.3.0"}); err != nil {
+		t.Fatalf("SetDependency failed: %v", err)
+	}
+	if err := editor.SetDependency("server-dependencies", "Data", breadTypes.Dependency{Spec: "a/data@1.0.0"}); err != nil {
+		t.Fatalf("SetDependency failed: %v", err)
+	}
+
+	want := `# My game
+[package]
+name = "me/game"
+version = "0.1.0"
+
+[place]
+shared-packages = "game.ReplicatedStorage.Packages"
+
+[dependencies]
+  # networking
+  Net = "sleitnick/net@0.3.0" # pinned
+  Signal = { package = "sleitnick/signal", version = "^2.0.0" }
+  Tools = [
+    "a",
+    "b",
+  ]
+  Promise = "evaera/promise@4.0.0"
+
+[dev-dependencies.TestEZ]
+package = "roblox/testez"
+version = "0.4.1"
+
+[server-dependencies]
+Data = "a/data@1.0.0"
+`
+	if got := editor.String(); got != want {
+		t.Errorf("Unexpected manifest.\nGot:\n%s\nExpected:\n%s", got, want)
+	}
+}
+
+func TestManifestEditorRemove(t *testing.T) {
+	editor, path := openTestManifest(t, editorManifest)
+
+	if !editor.RemoveDependency("dependencies", "Tools") {
+		t.Errorf("Expected Tools to be removed")
+	}
+	if !editor.RemoveDependency("dev-dependencies", "TestEZ") {
+		t.Errorf("Expected the TestEZ sub-table to be removed")
+	}
+	if editor.RemoveDependency("dependencies", "Missing") {
+		t.Errorf("Expected Missing to not be found")
+	}
+	if err := editor.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	var config struct {
+		breadTypes.Config
+		Place map[string]string `toml:"place"`
+	}
+	if _, err := toml.DecodeFile(path, &config); err != nil {
+		t.Fatalf("Edited manifest doesn't parse: %v", err)
+	}
+
+	if _, ok := config.Dependencies["Tools"]; ok {
+		t.Errorf("Tools still in dependencies")
+	}
+	if len(config.Dependencies) != 2 {
+		t.Errorf("Expected Net and Signal to stay, got %v", config.Dependencies)
+	}
+	if len(config.DevDependencies) != 0 {
+		t.Errorf("Expected no dev dependencies, got %v", config.DevDependencies)
+	}
+	if config.Place["shared-packages"] != "game.ReplicatedStorage.Packages" {
+		t.Errorf("Expected [place] to be kept, got %v", config.Place)
+	}
+}
+
+func TestManifestEditorAddsMissingTable(t *testing.T) {
+	editor, _ := openTestManifest(t, "[package]\nname = \"me/game\"\n\n\n")
+
+	if err := editor.SetDependency("dependencies", "Roact Hooks", breadTypes.Dependency{Package: "a/hooks", Version: "1.0.0"}); err != nil {
+		t.Fatalf("SetDependency failed: %v", err)
+	}
+
+	want := "[package]\nname = \"me/game\"\n\n[dependencies]\n\"Roact Hooks\" = { package = \"a/hooks\", version = \"1.0.0\" }\n"
+	if got := editor.String(); got != want {
+		t.Errorf("Unexpected manifest.\nGot:\n%q\nExpected:\n%q", got, want)
+	}
+}
+
+func TestManifestEditorSetStringQuotes(t *testing.T) {
+	editor, _ := openTestManifest(t, "[package]\nname = \"me/game\"\n")
+
+	for key, value := range map[string]string{"shared_dir": `C:\Packages`, "my.key": "say \"hi\"", "dev-dir": "Dev"} {
+		if err := editor.SetString("bread", key, value); err != nil {
+			t.Fatalf("SetString failed: %v", err)
+		}
+	}
+
+	var config struct {
+		Bread map[string]string `toml:"bread"`
+	}
+	if _, err := toml.Decode(editor.String(), &config); err != nil {
+		t.Fatalf("Edited manifest doesn't parse: %v\n%s", err, editor.String())
+	}
+	if config.Bread["shared_dir"] != `C:\Packages` || config.Bread["my.key"] != "say \"hi\"" || config.Bread["dev-dir"] != "Dev" {
+		t.Errorf("Unexpected [bread] %v", config.Bread)
+	}
+	if got := editor.String(); !strings.Contains(got, "\n\"my.key\" = ") || !strings.Contains(got, "\ndev-dir = ") {
+		t.Errorf("Expected only the dotted key quoted, got:\n%s", got)
+	}
+}
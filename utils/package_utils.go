@@ -11,23 +11,55 @@ import (
 	"yoheiyayoi/bread/breadTypes"
 
 	"github.com/BurntSushi/toml"
+	"github.com/charmbracelet/log"
 )
 
-func (ic *InstallationContext) getPackageDependencies(name, version string, realm Realm) (map[string]string, error) {
+func (ic *InstallationContext) getPackageDependencies(name, version string, realm Realm) (map[string]breadTypes.Dependency, error) {
 	fullName := packageIDFileName(name, version)
 	shortName := getPackageName(name)
 	packageDir := filepath.Join(ic.getIndexDir(realm), fullName, shortName)
 
-	configPath := filepath.Join(packageDir, "bread.toml")
-	if _, err := os.Stat(configPath); err == nil {
-		var config breadTypes.Config
-		if _, err := toml.DecodeFile(configPath, &config); err != nil {
-			return nil, err
+	config, err := readPackageManifest(packageDir)
+	if err != nil || config == nil {
+		return nil, err
+	}
+	return config.Dependencies, nil
+}
+
+// ManifestPath returns the manifest of dir: bread.toml, or wally.toml if there is only that.
+// Defaults to bread.toml when neither exists.
+func ManifestPath(dir string) string {
+	for _, file := range []string{"bread.toml", "wally.toml"} {
+		path := filepath.Join(dir, file)
+		if _, err := os.Stat(path); err == nil {
+			return path
 		}
-		return config.Dependencies, nil
+	}
+	return filepath.Join(dir, "bread.toml")
+}
+
+// readPackageManifest reads bread.toml, or wally.toml if there is none.
+// Returns nil if the directory has neither.
+func readPackageManifest(packageDir string) (*breadTypes.Config, error) {
+	configPath := ManifestPath(packageDir)
+	if _, err := os.Stat(configPath); err != nil {
+		return nil, nil // No config found
 	}
 
-	return nil, nil // No config found
+	var config breadTypes.Config
+	if _, err := toml.DecodeFile(configPath, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", configPath, err)
+	}
+	return &config, nil
+}
+
+// NormalizeDependency fills Package and Version of a registry dependency written in the string form,
+// so the installer only has to deal with one shape.
+func NormalizeDependency(alias string, dep breadTypes.Dependency) breadTypes.Dependency {
+	if dep.IsRegistry() && dep.Spec != "" {
+		dep.Package, dep.Version = ParsePackageSpec(alias, dep.Spec)
+	}
+	return dep
 }
 
 func ParsePackageSpec(alias, versionSpec string) (packageName, version string) {
@@ -41,11 +73,27 @@ func ParsePackageSpec(alias, versionSpec string) (packageName, version string) {
 	return alias, versionSpec
 }
 
-func (ic *InstallationContext) downloadPackage(name, version string, realm Realm) error {
+func (ic *InstallationContext) downloadPackage(api, name, version string, realm Realm) error {
+	if linked, err := ic.keepLink(name, version, realm); linked || err != nil {
+		return err
+	}
+
+	packageDirName := packageIDFileName(name, version)
+	targetDir := filepath.Join(ic.getIndexDir(realm), packageDirName)
+
+	if err := ic.downloadPackageTo(api, name, version, targetDir); err != nil {
+		return err
+	}
+
+	return ic.applyPatches(name, version, filepath.Join(targetDir, getPackageName(name)))
+}
+
+// downloadPackageTo fetches the package archive and extracts it fresh into targetDir
+func (ic *InstallationContext) downloadPackageTo(api, name, version, targetDir string) error {
 	downloadLimit <- struct{}{}
 	defer func() { <-downloadLimit }()
 
-	url := fmt.Sprintf("https://api.wally.run/v1/package-contents/%s/%s", name, version)
+	url := fmt.Sprintf("%s/v1/package-contents/%s/%s", api, name, version)
 
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -82,10 +130,12 @@ func (ic *InstallationContext) downloadPackage(name, version string, realm Realm
 		return err
 	}
 
-	packageDirName := packageIDFileName(name, version)
-	targetDir := filepath.Join(ic.getIndexDir(realm), packageDirName)
+	// Start clean so files left by an older extraction or patch don't linger
+	if err := os.RemoveAll(targetDir); err != nil {
+		return err
+	}
 
-	return unzipPackage(tmpFile.Name(), targetDir, name)
+	return unzipPackage(tmpFile.Name(), targetDir, name, ic.extractOptions(name))
 }
 
 func packageIDFileName(name, version string) string {
@@ -103,31 +153,56 @@ func getPackageName(name string) string {
 	return name
 }
 
-func unzipPackage(src, dest, packageName string) error {
+// unzipPackage extracts a package into dest/<name> once the archive passes checkArchive.
+// Files matching the exclude globs are left out, the globs and those files are recorded in dest/.bread-excluded.
+func unzipPackage(src, dest, packageName string, opts extractOptions) error {
 	r, err := zip.OpenReader(src)
 	if err != nil {
 		return err
 	}
 	defer r.Close()
 
+	links, err := checkArchive(r.File, opts)
+	if err != nil {
+		return fmt.Errorf("refusing to extract %s: %w", packageName, err)
+	}
+
 	packageDir := filepath.Join(dest, getPackageName(packageName))
 	if err := os.MkdirAll(packageDir, 0755); err != nil {
 		return err
 	}
 
+	var excluded []excludedFile
 	for _, f := range r.File {
-		fpath := filepath.Join(packageDir, f.Name)
+		name, _ := archivePath(f.Name) // checked above
+		fpath := filepath.Join(packageDir, filepath.FromSlash(name))
 
-		// Basic Zip Slip protection
+		// Zip Slip protection, in case a path got past checkArchive
 		if !strings.HasPrefix(fpath, filepath.Clean(packageDir)+string(os.PathSeparator)) {
 			return fmt.Errorf("illegal file path: %s", fpath)
 		}
 
+		if isExcluded(opts.exclude, name) {
+			if !f.FileInfo().IsDir() {
+				excluded = append(excluded, excludedFile{Path: name, Size: int64(f.UncompressedSize64)})
+			}
+			continue
+		}
+
 		if f.FileInfo().IsDir() {
 			os.MkdirAll(fpath, 0755)
 			continue
 		}
 
+		if f.Mode()&os.ModeSymlink != 0 {
+			target, ok := links[name]
+			if !ok {
+				log.Warnf("Skipping %s in %s, it doesn't link to a file in the package", name, packageName)
+				continue
+			}
+			f = target // a copy of the file works everywhere a link may not
+		}
+
 		if err := os.MkdirAll(filepath.Dir(fpath), 0755); err != nil {
 			return err
 		}
@@ -136,22 +211,9 @@ func unzipPackage(src, dest, packageName string) error {
 			return err
 		}
 	}
-	return nil
-}
-
-func extractFile(f *zip.File, destPath string) error {
-	outFile, err := os.Create(destPath)
-	if err != nil {
-		return err
-	}
-	defer outFile.Close()
 
-	rc, err := f.Open()
-	if err != nil {
-		return err
+	if len(opts.exclude) > 0 {
+		return writeExcludedRecord(dest, excludedRecord{Package: packageName, Exclude: opts.exclude, Files: excluded})
 	}
-	defer rc.Close()
-
-	_, err = io.Copy(outFile, rc)
-	return err
+	return nil
 }
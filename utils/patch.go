@@ -0,0 +1,183 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"yoheiyayoi/bread/breadTypes"
+
+	"github.com/charmbracelet/log"
+)
+
+// PatchesDirName holds the recorded patches, next to bread.lock
+const PatchesDirName = "patches"
+
+func (ic *InstallationContext) patchesDir() string {
+	return filepath.Join(ic.lockRoot(), PatchesDirName)
+}
+
+// patchEditDir is where bread patch puts the editable copy of a package
+func (ic *InstallationContext) patchEditDir(name, version string) string {
+	return filepath.Join(ic.ProjectPath, ".bread", "patch", packageIDFileName(name, version))
+}
+
+// findPatches returns the recorded patches of a package, for any version
+func (ic *InstallationContext) findPatches(name string) ([]string, error) {
+	pattern := filepath.Join(ic.patchesDir(), packageIDFileName(name, "*")+".patch")
+	return filepath.Glob(pattern)
+}
+
+// applyPatches re-applies the recorded patches after a package is extracted.
+// A patch that doesn't apply anymore fails the install instead of leaving a half patched package.
+func (ic *InstallationContext) applyPatches(name, version, packageDir string) error {
+	patches, err := ic.findPatches(name)
+	if err != nil {
+		return err
+	}
+
+	for _, patchPath := range patches {
+		content, err := os.ReadFile(patchPath)
+		if err != nil {
+			return err
+		}
+
+		expected := packageIDFileName(name, version) + ".patch"
+		if filepath.Base(patchPath) != expected {
+			log.Warnf("Patch %s was made for another version of %s@%s", filepath.Base(patchPath), name, version)
+		}
+
+		if err := applyPatch(packageDir, string(content)); err != nil {
+			return fmt.Errorf("patch %s no longer applies to %s@%s: %w (run bread patch %s to update it)", filepath.Base(patchPath), name, version, err, name)
+		}
+	}
+
+	return nil
+}
+
+// lockedRegistryPackage finds the locked version of a registry package and its registry API.
+// version picks one when several are locked, it's empty otherwise.
+func (ic *InstallationContext) lockedRegistryPackage(name, version string) (string, string, error) {
+	locked, ok := ic.Lockfile[name]
+	if !ok || len(locked) == 0 {
+		return "", "", fmt.Errorf("%s is not installed, run bread install first", name)
+	}
+
+	var candidates []breadTypes.LockedPackage
+	for _, pkg := range locked {
+		if version == "" || pkg.Version == version {
+			candidates = append(candidates, pkg)
+		}
+	}
+	switch {
+	case len(candidates) == 0:
+		return "", "", fmt.Errorf("%s@%s is not installed", name, version)
+	case len(candidates) > 1:
+		versions := make([]string, len(candidates))
+		for i, pkg := range candidates {
+			versions[i] = pkg.Version
+		}
+		return "", "", fmt.Errorf("%s has several versions installed (%s), pass %s@<version> to pick one", name, strings.Join(versions, ", "), name)
+	}
+
+	pkg := candidates[0]
+	switch {
+	case strings.HasPrefix(pkg.Source, "path+"):
+		return "", "", fmt.Errorf("%s is a path dependency, edit its source directly", name)
+	case strings.HasPrefix(pkg.Source, "git+"):
+		return "", "", fmt.Errorf("%s is a git dependency, patching is only supported for registry packages", name)
+	}
+
+	api, err := RegistryAPI(strings.TrimPrefix(pkg.Source, "registry+"))
+	if err != nil {
+		return "", "", err
+	}
+	return pkg.Version, api, nil
+}
+
+// PreparePatch extracts an editable copy of an installed package, with its current patch applied.
+// spec is scope/name, or scope/name@version when several versions are installed.
+func (ic *InstallationContext) PreparePatch(spec string) (string, error) {
+	name, version, _ := strings.Cut(spec, "@")
+	version, api, err := ic.lockedRegistryPackage(name, version)
+	if err != nil {
+		return "", err
+	}
+
+	editDir := ic.patchEditDir(name, version)
+	packageDir := filepath.Join(editDir, getPackageName(name))
+	if _, err := os.Stat(packageDir); err == nil {
+		return packageDir, nil
+	}
+
+	if err := ic.downloadPackageTo(api, name, version, editDir); err != nil {
+		return "", err
+	}
+
+	if err := ic.applyPatches(name, version, packageDir); err != nil {
+		os.RemoveAll(editDir)
+		return "", err
+	}
+
+	return packageDir, nil
+}
+
+// CommitPatch diffs the edited copy against a fresh download and saves it under patches/.
+// Returns the patch path, or an empty string if nothing was changed.
+func (ic *InstallationContext) CommitPatch(spec string) (string, error) {
+	name, version, _ := strings.Cut(spec, "@")
+	version, api, err := ic.lockedRegistryPackage(name, version)
+	if err != nil {
+		return "", err
+	}
+
+	editDir := ic.patchEditDir(name, version)
+	if _, err := os.Stat(editDir); err != nil {
+		return "", fmt.Errorf("%s is not being patched, run bread patch %s first", name, spec)
+	}
+
+	pristineDir, err := os.MkdirTemp("", "bread-patch-*")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(pristineDir)
+
+	if err := ic.downloadPackageTo(api, name, version, pristineDir); err != nil {
+		return "", err
+	}
+
+	shortName := getPackageName(name)
+	patch, err := diffDirs(filepath.Join(pristineDir, shortName), filepath.Join(editDir, shortName))
+	if err != nil {
+		return "", err
+	}
+
+	// The new patch replaces patches made for other versions
+	oldPatches, err := ic.findPatches(name)
+	if err != nil {
+		return "", err
+	}
+	for _, oldPatch := range oldPatches {
+		if err := os.Remove(oldPatch); err != nil {
+			return "", err
+		}
+	}
+
+	if err := os.RemoveAll(editDir); err != nil {
+		return "", err
+	}
+
+	if patch == "" {
+		return "", nil
+	}
+
+	if err := os.MkdirAll(ic.patchesDir(), 0755); err != nil {
+		return "", err
+	}
+
+	patchPath := filepath.Join(ic.patchesDir(), packageIDFileName(name, version)+".patch")
+	if err := os.WriteFile(patchPath, []byte(patch), 0644); err != nil {
+		return "", err
+	}
+	return patchPath, nil
+}
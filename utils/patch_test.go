@@ -0,0 +1,28 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+func TestLockedRegistryPackageVersion(t *testing.T) {
+	ic := &InstallationContext{Lockfile: map[string][]breadTypes.LockedPackage{
+		"sleitnick/signal": {
+			{Name: "sleitnick/signal", Version: "1.5.0"},
+			{Name: "sleitnick/signal", Version: "2.0.0"},
+		},
+	}}
+
+	if _, _, err := ic.lockedRegistryPackage("sleitnick/signal", ""); err == nil || !strings.Contains(err.Error(), "sleitnick/signal@<version>") {
+		t.Errorf("Expected an error asking for a version, got %v", err)
+	}
+	if _, _, err := ic.lockedRegistryPackage("sleitnick/signal", "3.0.0"); err == nil {
+		t.Errorf("Expected an error for a version that isn't locked")
+	}
+
+	version, _, err := ic.lockedRegistryPackage("sleitnick/signal", "1.5.0")
+	if err != nil || version != "1.5.0" {
+		t.Errorf("Expected 1.5.0, got %q %v", version, err)
+	}
+}
@@ -0,0 +1,283 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"yoheiyayoi/bread/breadTypes"
+
+	"github.com/BurntSushi/toml"
+)
+
+// pesdeManifest is the part of pesde.toml bread can use
+type pesdeManifest struct {
+	Name         string                     `toml:"name"`
+	Version      string                     `toml:"version"`
+	Description  string                     `toml:"description"`
+	License      string                     `toml:"license"`
+	Authors      []string                   `toml:"authors"`
+	Repository   string                     `toml:"repository"`
+	Private      bool                       `toml:"private"`
+	Target       pesdeTarget                `toml:"target"`
+	WallyIndices map[string]string          `toml:"wally_indices"`
+	Dependencies map[string]pesdeDependency `toml:"dependencies"`
+	Peer         map[string]pesdeDependency `toml:"peer_dependencies"`
+	Dev          map[string]pesdeDependency `toml:"dev_dependencies"`
+}
+
+type pesdeTarget struct {
+	Environment string `toml:"environment"` // roblox, roblox_server, luau or lune
+}
+
+type pesdeDependency struct {
+	Wally     string `toml:"wally"`
+	Name      string `toml:"name"` // pesde registry
+	Repo      string `toml:"repo"`
+	Rev       string `toml:"rev"`
+	Path      string `toml:"path"`
+	Workspace string `toml:"workspace"`
+	Version   string `toml:"version"`
+	Index     string `toml:"index"`
+	Target    string `toml:"target"`
+}
+
+// ImportPesde writes bread.toml and bread.lock from pesde.toml and pesde.lock.
+// Wally, git and path dependencies carry over, everything else is listed in Skipped.
+func ImportPesde(projectPath string) (*ImportResult, error) {
+	breadPath := filepath.Join(projectPath, "bread.toml")
+	if _, err := os.Stat(breadPath); err == nil {
+		return nil, fmt.Errorf("bread.toml already exists")
+	}
+
+	var manifest pesdeManifest
+	if _, err := toml.DecodeFile(filepath.Join(projectPath, "pesde.toml"), &manifest); err != nil {
+		return nil, fmt.Errorf("failed to read pesde.toml: %w", err)
+	}
+
+	result := &ImportResult{}
+	config := breadTypes.Config{
+		Package: breadTypes.Package{
+			Name:        manifest.Name,
+			Description: manifest.Description,
+			Version:     manifest.Version,
+			License:     manifest.License,
+			Authors:     manifest.Authors,
+			Repository:  manifest.Repository,
+			Private:     manifest.Private,
+			Registry:    manifest.WallyIndices["default"],
+			Realm:       "shared",
+		},
+		BreadConfig: breadTypes.BreadConfig{
+			PackagesDir: "Packages",
+			ServerDir:   "ServerPackages",
+			DevDir:      "DevPackages",
+		},
+		Dependencies:       map[string]breadTypes.Dependency{},
+		ServerDependencies: map[string]breadTypes.Dependency{},
+		DevDependencies:    map[string]breadTypes.Dependency{},
+	}
+	if config.Package.Registry == "" {
+		config.Package.Registry = DefaultRegistry
+	}
+
+	// A roblox_server package only runs on the server, so do its dependencies
+	shared := config.Dependencies
+	switch manifest.Target.Environment {
+	case "roblox_server":
+		config.Package.Realm = "server"
+		shared = config.ServerDependencies
+	case "roblox", "":
+	default:
+		result.Skipped = append(result.Skipped, fmt.Sprintf("target %q has no bread realm, its dependencies are shared", manifest.Target.Environment))
+	}
+
+	add := func(table map[string]breadTypes.Dependency, kind string, deps map[string]pesdeDependency) {
+		for _, alias := range sortedKeys(deps) {
+			pd := deps[alias]
+			dep, err := manifest.convertDependency(pd)
+			if err != nil {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("%s %s: %s", kind, alias, err))
+				continue
+			}
+
+			target := table
+			if pd.Target == "roblox_server" && kind != "dev dependency" {
+				target = config.ServerDependencies
+			}
+			target[alias] = dep
+			result.Dependencies++
+		}
+	}
+	add(shared, "dependency", manifest.Dependencies)
+	add(shared, "peer dependency", manifest.Peer)
+	add(config.DevDependencies, "dev dependency", manifest.Dev)
+
+	lockfile, err := readPesdeLock(filepath.Join(projectPath, "pesde.lock"), result)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Create(breadPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	if err := toml.NewEncoder(file).Encode(config); err != nil {
+		return nil, err
+	}
+
+	if lockfile != nil {
+		root := breadTypes.LockedPackage{Name: config.Package.Name, Version: config.Package.Version}
+		for _, table := range []map[string]breadTypes.Dependency{config.Dependencies, config.ServerDependencies, config.DevDependencies} {
+			for alias, dep := range table {
+				root.Dependencies = append(root.Dependencies, []string{alias, dep.String()})
+			}
+		}
+		sort.Slice(root.Dependencies, func(i, j int) bool {
+			return root.Dependencies[i][0] < root.Dependencies[j][0]
+		})
+		lockfile.Registry = lockRegistry(&config)
+		lockfile.Packages = append(lockfile.Packages, root)
+		sort.Slice(lockfile.Packages, func(i, j int) bool {
+			return lockfile.Packages[i].Name < lockfile.Packages[j].Name
+		})
+
+		if err := writeLockfileTo(filepath.Join(projectPath, "bread.lock"), "Bread", *lockfile); err != nil {
+			return nil, err
+		}
+		result.Locked = len(lockfile.Packages) - 1
+	}
+
+	return result, nil
+}
+
+func (m *pesdeManifest) convertDependency(pd pesdeDependency) (breadTypes.Dependency, error) {
+	switch {
+	case pd.Wally != "":
+		registry := ""
+		if pd.Index != "" && pd.Index != "default" {
+			url, ok := m.WallyIndices[pd.Index]
+			if !ok {
+				return breadTypes.Dependency{}, fmt.Errorf("unknown wally index %q", pd.Index)
+			}
+			registry = url
+		}
+
+		if registry == "" {
+			return breadTypes.Dependency{Spec: pd.Wally + "@" + pd.Version}, nil
+		}
+		return breadTypes.Dependency{Package: pd.Wally, Version: pd.Version, Registry: registry}, nil
+
+	case pd.Repo != "":
+		repo := pd.Repo
+		// pesde accepts owner/repo for GitHub
+		if !strings.Contains(repo, "://") && !strings.Contains(repo, "@") && strings.Count(repo, "/") == 1 {
+			repo = "https://github.com/" + repo + ".git"
+		}
+		return breadTypes.Dependency{Git: repo, Rev: pd.Rev}, nil
+
+	case pd.Path != "":
+		return breadTypes.Dependency{Path: pd.Path}, nil
+
+	case pd.Name != "":
+		return breadTypes.Dependency{}, fmt.Errorf("%s is on the pesde registry, bread only installs from Wally registries", pd.Name)
+
+	case pd.Workspace != "":
+		return breadTypes.Dependency{}, fmt.Errorf("workspace dependency %s, add it to [workspace] by hand", pd.Workspace)
+	}
+
+	return breadTypes.Dependency{}, fmt.Errorf("unknown dependency kind")
+}
+
+// readPesdeLock turns the Wally packages of pesde.lock into bread.lock entries.
+// Returns nil if there is no pesde.lock.
+func readPesdeLock(path string, result *ImportResult) (*breadTypes.Lockfile, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var lock struct {
+		Graph map[string]any `toml:"graph"`
+	}
+	if _, err := toml.DecodeFile(path, &lock); err != nil {
+		return nil, fmt.Errorf("failed to parse pesde.lock: %w", err)
+	}
+
+	// Older lockfiles nest "source#name" -> "version target" -> node,
+	// newer ones use "source#name@version target" -> node
+	nodes := make(map[string]map[string]any)
+	for id, value := range lock.Graph {
+		node, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		if strings.Contains(id, "@") {
+			nodes[id] = node
+			continue
+		}
+		for versionTarget, nested := range node {
+			if n, ok := nested.(map[string]any); ok {
+				nodes[id+"@"+versionTarget] = n
+			}
+		}
+	}
+
+	lockfile := &breadTypes.Lockfile{}
+	for _, id := range sortedKeys(nodes) {
+		name, version, ok := parsePesdeID(id)
+		if !ok {
+			result.Skipped = append(result.Skipped, fmt.Sprintf("locked %s: not a Wally package", id))
+			continue
+		}
+
+		pkg := breadTypes.LockedPackage{Name: name, Version: version, Dependencies: [][]string{}}
+		deps, _ := nodes[id]["dependencies"].(map[string]any)
+		for _, alias := range sortedKeys(deps) {
+			depID := ""
+			switch v := deps[alias].(type) {
+			case string:
+				depID = v
+			case []any:
+				if len(v) == 2 {
+					depID = fmt.Sprintf("%v@%v", v[0], v[1])
+				}
+			}
+
+			depName, depVersion, ok := parsePesdeID(depID)
+			if !ok {
+				result.Skipped = append(result.Skipped, fmt.Sprintf("locked %s -> %s: not a Wally package", name, alias))
+				continue
+			}
+			pkg.Dependencies = append(pkg.Dependencies, []string{alias, depName + "@" + depVersion})
+		}
+		lockfile.Packages = append(lockfile.Packages, pkg)
+	}
+
+	return lockfile, nil
+}
+
+// parsePesdeID splits "wally#scope/name@1.0.0 roblox", only Wally packages map onto bread
+func parsePesdeID(id string) (name, version string, ok bool) {
+	rest, found := strings.CutPrefix(id, "wally#")
+	if !found {
+		return "", "", false
+	}
+	name, versionTarget, found := strings.Cut(rest, "@")
+	if !found {
+		return "", "", false
+	}
+	version, _, _ = strings.Cut(versionTarget, " ")
+	return name, version, version != ""
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
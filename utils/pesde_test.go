@@ -0,0 +1,78 @@
+package utils
+
+import (
+	"path/filepath"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestImportPesde(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"pesde.toml": `name = "me/game"
+version = "0.1.0"
+
+[target]
+environment = "roblox"
+
+[wally_indices]
+default = "https://github.com/UpliftGames/wally-index"
+
+[dependencies]
+Promise = { wally = "evaera/promise", version = "^4.0.0" }
+Data = { wally = "a/data", version = "^1.0.0", target = "roblox_server" }
+Hello = { name = "pesde/hello", version = "^1.0.0" }
+
+[dev_dependencies]
+TestEZ = { wally = "roblox/testez", version = "^0.4.1" }
+`,
+		"pesde.lock": `name = "me/game"
+version = "0.1.0"
+
+[graph."wally#evaera/promise"."4.0.2 roblox"]
+resolved_ty = "standard"
+
+[graph."wally#a/data"."1.2.0 roblox".dependencies]
+Promise = ["wally#evaera/promise", "4.0.2 roblox"]
+
+[graph."pesde/hello"."1.0.0 roblox"]
+resolved_ty = "standard"
+`,
+	})
+
+	result, err := ImportPesde(dir)
+	if err != nil {
+		t.Fatalf("ImportPesde failed: %v", err)
+	}
+	if result.Dependencies != 3 || result.Locked != 2 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(result.Skipped) != 2 {
+		t.Errorf("Expected the pesde registry package to be reported twice, got %v", result.Skipped)
+	}
+
+	var config breadTypes.Config
+	if _, err := toml.DecodeFile(filepath.Join(dir, "bread.toml"), &config); err != nil {
+		t.Fatalf("Failed to read bread.toml: %v", err)
+	}
+	if config.Dependencies["Promise"].Spec != "evaera/promise@^4.0.0" {
+		t.Errorf("Unexpected Promise dependency: %+v", config.Dependencies["Promise"])
+	}
+	if _, ok := config.ServerDependencies["Data"]; !ok {
+		t.Errorf("Expected the roblox_server dependency in server-dependencies, got %v", config.ServerDependencies)
+	}
+	if _, ok := config.DevDependencies["TestEZ"]; !ok {
+		t.Errorf("Expected TestEZ in dev-dependencies, got %v", config.DevDependencies)
+	}
+
+	lockfile, err := readLockfile(filepath.Join(dir, "bread.lock"))
+	if err != nil || lockfile == nil {
+		t.Fatalf("Failed to read bread.lock: %v", err)
+	}
+	data := lockfile.Packages[0]
+	if data.Name != "a/data" || data.Version != "1.2.0" || len(data.Dependencies) != 1 || data.Dependencies[0][1] != "evaera/promise@4.0.2" {
+		t.Errorf("Unexpected locked a/data: %+v", data)
+	}
+}
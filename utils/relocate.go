@@ -0,0 +1,346 @@
+package utils
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+var luauIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// RelocateResult is what Relocate changed, or would change on a dry run
+type RelocateResult struct {
+	Moved []string // "Packages -> Shared"
+	Files []string // sources with rewritten requires, relative to the project
+	Diff  string   // unified diff of the manifest and every rewritten source
+}
+
+// folderRename is one realm folder changing place
+type folderRename struct {
+	realm    Realm
+	key      string // key in [bread]
+	from, to string // absolute directories
+}
+
+// Relocate moves realm folders to new directories (relative to the project), updates [bread] and [place]
+// and rewrites the paths in .lua/.luau sources that reach the folders by name, e.g. ReplicatedStorage.Packages.
+func (ic *InstallationContext) Relocate(dirs map[Realm]string, dryRun bool) (*RelocateResult, error) {
+	manifestPath := ManifestPath(ic.ProjectPath)
+	if filepath.Base(manifestPath) != "bread.toml" {
+		return nil, fmt.Errorf("wally.toml can't configure package folders, run bread import first")
+	}
+
+	keys := map[Realm]string{RealmShared: "shared_dir", RealmServer: "server_dir", RealmDev: "dev_dir"}
+
+	var renames []folderRename
+	for _, realm := range []Realm{RealmShared, RealmServer, RealmDev} {
+		dir, ok := dirs[realm]
+		if !ok {
+			continue
+		}
+		if filepath.IsAbs(dir) {
+			return nil, fmt.Errorf("%s must be relative to the project", dir)
+		}
+		if !luauIdentifier.MatchString(filepath.Base(dir)) {
+			return nil, fmt.Errorf("%s can't be used in a require path, use letters, digits and _", filepath.Base(dir))
+		}
+
+		from := ic.getRealmDir(realm)
+		to := filepath.Join(ic.ProjectPath, dir)
+		if from == to {
+			continue
+		}
+		if _, err := os.Stat(to); err == nil {
+			return nil, fmt.Errorf("%s already exists", dir)
+		}
+		renames = append(renames, folderRename{realm: realm, key: keys[realm], from: from, to: to})
+	}
+
+	result := &RelocateResult{}
+	if len(renames) == 0 {
+		return result, nil
+	}
+
+	// Manifest
+	editor, err := OpenManifest(manifestPath)
+	if err != nil {
+		return nil, err
+	}
+	before := editor.String()
+	for _, r := range renames {
+		rel, _ := filepath.Rel(ic.ProjectPath, r.to)
+		if err := editor.SetString("bread", r.key, filepath.ToSlash(rel)); err != nil {
+			return nil, err
+		}
+
+		// the Rojo mount named after the folder follows it
+		key, place := ic.placeOf(r.realm)
+		if moved := renamePlace(place, filepath.Base(r.from), filepath.Base(r.to)); moved != place {
+			if err := editor.SetString("place", key, moved); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var diff strings.Builder
+	diff.WriteString(unifiedDiff("a/bread.toml", "b/bread.toml", before, editor.String()))
+
+	// Sources
+	rewrites, err := ic.findRequireRewrites(renames)
+	if err != nil {
+		return nil, err
+	}
+	for _, rw := range rewrites {
+		diff.WriteString(unifiedDiff("a/"+rw.path, "b/"+rw.path, rw.before, rw.after))
+		result.Files = append(result.Files, rw.path)
+	}
+	result.Diff = diff.String()
+
+	for _, r := range renames {
+		from, _ := filepath.Rel(ic.ProjectPath, r.from)
+		to, _ := filepath.Rel(ic.ProjectPath, r.to)
+		result.Moved = append(result.Moved, fmt.Sprintf("%s -> %s", filepath.ToSlash(from), filepath.ToSlash(to)))
+	}
+
+	if dryRun {
+		return result, nil
+	}
+
+	// Move the folders first, if that fails nothing else has changed
+	for _, r := range renames {
+		if _, err := os.Stat(r.from); os.IsNotExist(err) {
+			continue // Not installed yet
+		}
+		if err := os.MkdirAll(filepath.Dir(r.to), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(r.from, r.to); err != nil {
+			return nil, fmt.Errorf("failed to move %s: %w", r.from, err)
+		}
+	}
+
+	for _, rw := range rewrites {
+		if err := os.WriteFile(filepath.Join(ic.ProjectPath, rw.path), []byte(rw.after), rw.mode); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := editor.Save(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// placeOf returns the [place] key of a realm and its value, empty if unset
+func (ic *InstallationContext) placeOf(realm Realm) (key, place string) {
+	var p breadTypes.Place
+	if ic.Manifest.Place != nil {
+		p = *ic.Manifest.Place
+	}
+	switch realm {
+	case RealmServer:
+		return "server-packages", p.ServerPackages
+	case RealmDev:
+		return "dev-packages", p.DevPackages
+	}
+	return "shared-packages", p.SharedPackages
+}
+
+// renamePlace renames the last instance of a place like game.ReplicatedStorage.Packages when it is the folder
+func renamePlace(place, from, to string) string {
+	i := strings.LastIndex(place, ".")
+	if i < 0 || place[i+1:] != from {
+		return place
+	}
+	return place[:i+1] + to
+}
+
+type requireRewrite struct {
+	path          string // relative to the project
+	before, after string
+	mode          fs.FileMode
+}
+
+// findRequireRewrites scans the project sources, skipping the realm folders themselves
+func (ic *InstallationContext) findRequireRewrites(renames []folderRename) ([]requireRewrite, error) {
+	skip := map[string]bool{
+		ic.SharedDir: true,
+		ic.ServerDir: true,
+		ic.DevDir:    true,
+	}
+
+	var rewrites []requireRewrite
+	err := filepath.WalkDir(ic.ProjectPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if path == ic.ProjectPath {
+				return nil
+			}
+			if skip[path] || strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules" {
+				return filepath.SkipDir
+			}
+			// Nested projects (workspace members, vendored packages) have folders of their own
+			if _, err := os.Stat(ManifestPath(path)); err == nil {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		ext := filepath.Ext(path)
+		if ext != ".lua" && ext != ".luau" {
+			return nil
+		}
+
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+
+		after := string(data)
+		for _, r := range renames {
+			after = rewriteFolderReferences(after, filepath.Base(r.from), filepath.Base(r.to))
+		}
+		if after == string(data) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(ic.ProjectPath, path)
+		rewrites = append(rewrites, requireRewrite{
+			path:   filepath.ToSlash(rel),
+			before: string(data),
+			after:  after,
+			mode:   info.Mode().Perm(),
+		})
+		return nil
+	})
+
+	return rewrites, err
+}
+
+// instance methods that look up a child by name, their result is an instance too
+var childLookups = map[string]bool{"WaitForChild": true, "FindFirstChild": true}
+
+// rewriteFolderReferences renames an instance in the paths Luau code reaches it by: Parent.Packages,
+// Parent["Packages"], :WaitForChild("Packages") and :FindFirstChild("Packages"). A path has to start at
+// game, script, a local holding an instance (e.g. from game:GetService) or a WaitForChild/FindFirstChild
+// call, so fields like self.Packages and anything in comments and strings are left alone.
+func rewriteFolderReferences(source, from, to string) string {
+	if from == to {
+		return source
+	}
+
+	tokens := lexLuau(source)
+	instances := map[string]bool{"game": true, "script": true} // names known to hold an instance
+	var found []luauToken
+
+	for i := 0; i < len(tokens); i++ {
+		exprStart := i
+		switch {
+		case tokens[i].kind == luauName && instances[tokens[i].text] && !isMemberName(tokens, i):
+		case tokens[i].is(":") && i+1 < len(tokens) && childLookups[tokens[i+1].text]:
+			// whatever it's called on, a child lookup is an instance path
+			if i > 0 && tokens[i-1].kind == luauName && !isMemberName(tokens, i-1) {
+				exprStart = i - 1
+			}
+		default:
+			continue
+		}
+
+		end, names := instanceChain(tokens, i, from)
+		found = append(found, names...)
+
+		// local Name = <instance path>
+		if exprStart >= 3 && tokens[exprStart-1].is("=") && tokens[exprStart-2].kind == luauName && tokens[exprStart-3].is("local") {
+			instances[tokens[exprStart-2].text] = true
+		}
+		i = max(end-1, i)
+	}
+
+	var out strings.Builder
+	last := 0
+	for _, tok := range found {
+		out.WriteString(source[last:tok.start])
+		if tok.kind == luauString {
+			out.WriteString(tok.text[:1] + to + tok.text[len(tok.text)-1:])
+		} else {
+			out.WriteString(to)
+		}
+		last = tok.end
+	}
+	out.WriteString(source[last:])
+	return out.String()
+}
+
+// isMemberName reports whether the name at i is a field, as in x.name or x:name
+func isMemberName(tokens []luauToken, i int) bool {
+	return i > 0 && (tokens[i-1].is(".") || tokens[i-1].is(":"))
+}
+
+// instanceChain follows the member accesses and child lookups of an instance path starting at
+// tokens[i]. Returns where the path ends and the tokens naming the folder.
+func instanceChain(tokens []luauToken, i int, name string) (int, []luauToken) {
+	var found []luauToken
+	if tokens[i].kind == luauName {
+		i++
+	}
+	for i < len(tokens) {
+		switch {
+		case tokens[i].is(".") && i+1 < len(tokens) && tokens[i+1].kind == luauName:
+			if tokens[i+1].text == name {
+				found = append(found, tokens[i+1])
+			}
+			i += 2
+
+		case tokens[i].is("[") && i+2 < len(tokens) && tokens[i+1].kind == luauString && tokens[i+2].is("]"):
+			if unquote(tokens[i+1].text) == name {
+				found = append(found, tokens[i+1])
+			}
+			i += 3
+
+		case tokens[i].is(":") && i+3 < len(tokens) && tokens[i+2].is("(") && tokens[i+3].kind == luauString:
+			method := tokens[i+1].text
+			if !childLookups[method] && method != "GetService" {
+				return i, found
+			}
+			closing := closingParen(tokens, i+2)
+			if closing < 0 {
+				return len(tokens), found
+			}
+			if childLookups[method] && unquote(tokens[i+3].text) == name {
+				found = append(found, tokens[i+3])
+			}
+			i = closing + 1
+
+		default:
+			return i, found
+		}
+	}
+	return i, found
+}
+
+// closingParen returns the index of the ) matching the ( at open, -1 if there isn't one
+func closingParen(tokens []luauToken, open int) int {
+	depth := 0
+	for i := open; i < len(tokens); i++ {
+		switch {
+		case tokens[i].is("("):
+			depth++
+		case tokens[i].is(")"):
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
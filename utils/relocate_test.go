@@ -0,0 +1,115 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRewriteFolderReferences(t *testing.T) {
+	tests := []struct {
+		source, want string
+	}{
+		{"local ReplicatedStorage = game:GetService(\"ReplicatedStorage\")\nrequire(ReplicatedStorage.Packages.Promise)", "local ReplicatedStorage = game:GetService(\"ReplicatedStorage\")\nrequire(ReplicatedStorage.Shared.Promise)"},
+		{"require(game:GetService(\"ReplicatedStorage\").Packages.Promise)", "require(game:GetService(\"ReplicatedStorage\").Shared.Promise)"},
+		{"local P = ReplicatedStorage:WaitForChild(\"Packages\")", "local P = ReplicatedStorage:WaitForChild(\"Shared\")"},
+		{"local P = game.ReplicatedStorage:FindFirstChild('Packages')", "local P = game.ReplicatedStorage:FindFirstChild('Shared')"},
+		{"require(script.Parent.Parent[\"Packages\"].Promise)", "require(script.Parent.Parent[\"Shared\"].Promise)"},
+		{"return script.Parent.Packages", "return script.Parent.Shared"},
+		// Locals and other names are left alone
+		{"local Packages = {}\nprint(Packages.Promise)", "local Packages = {}\nprint(Packages.Promise)"},
+		{"require(ReplicatedStorage.PackagesOld.X)", "require(ReplicatedStorage.PackagesOld.X)"},
+		{"print(\"a\"..Packages)", "print(\"a\"..Packages)"},
+		{"require(ReplicatedStorage.Packages.Promise)", "require(ReplicatedStorage.Packages.Promise)"},
+		{"self.Packages = {}\nreturn Config.Packages", "self.Packages = {}\nreturn Config.Packages"},
+		{"-- game.ReplicatedStorage.Packages\nlocal s = \"script.Parent.Packages\"", "-- game.ReplicatedStorage.Packages\nlocal s = \"script.Parent.Packages\""},
+		{"local t = { Packages = script.Parent.Packages }", "local t = { Packages = script.Parent.Shared }"},
+	}
+
+	for _, tt := range tests {
+		if got := rewriteFolderReferences(tt.source, "Packages", "Shared"); got != tt.want {
+			t.Errorf("rewriteFolderReferences(%q) = %q, expected %q", tt.source, got, tt.want)
+		}
+	}
+}
+
+func TestRelocate(t *testing.T) {
+	client := `local ReplicatedStorage = game:GetService("ReplicatedStorage")
+local Promise = require(ReplicatedStorage.Packages.Promise)
+
+local Store = {}
+Store.__index = Store
+
+function Store.new()
+	local self = setmetatable({}, Store)
+	self.Packages = {} -- Packages the player owns
+	return self
+end
+
+return Store
+`
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n\n" +
+			"[place]\nshared-packages = \"game.ReplicatedStorage.Packages\"\nserver-packages = \"game.ServerScriptService.Server\"\n",
+		"Packages/Promise.lua": "return require(script.Parent._Index[\"evaera_promise@4.0.2\"].promise)\n",
+		"Packages/_Index/evaera_promise@4.0.2/promise/init.lua": "return {}\n",
+		"src/Client.lua": client,
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+
+	result, err := ic.Relocate(map[Realm]string{RealmShared: "Shared"}, true)
+	if err != nil {
+		t.Fatalf("Relocate dry run failed: %v", err)
+	}
+	if len(result.Files) != 1 || result.Files[0] != "src/Client.lua" {
+		t.Errorf("Expected src/Client.lua to be rewritten, got %v", result.Files)
+	}
+	for _, want := range []string{"+shared_dir = \"Shared\"", "+shared-packages = \"game.ReplicatedStorage.Shared\"", "+local Promise = require(ReplicatedStorage.Shared.Promise)"} {
+		if !strings.Contains(result.Diff, want) {
+			t.Errorf("Expected the diff to contain %q, got:\n%s", want, result.Diff)
+		}
+	}
+	if strings.Contains(result.Diff, "self.Shared") || strings.Contains(result.Diff, "Shared the player owns") {
+		t.Errorf("Expected the self.Packages field and comment to be left alone, got:\n%s", result.Diff)
+	}
+
+	// a dry run changes nothing
+	if data, _ := os.ReadFile(filepath.Join(dir, "src", "Client.lua")); string(data) != client {
+		t.Errorf("Expected the dry run to leave the source alone, got:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Packages")); err != nil {
+		t.Errorf("Expected the dry run to leave Packages in place")
+	}
+
+	if _, err := ic.Relocate(map[Realm]string{RealmShared: "Shared"}, false); err != nil {
+		t.Fatalf("Relocate failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "src", "Client.lua"))
+	if err != nil {
+		t.Fatalf("Failed to read src/Client.lua: %v", err)
+	}
+	if want := strings.Replace(client, "ReplicatedStorage.Packages.Promise", "ReplicatedStorage.Shared.Promise", 1); string(data) != want {
+		t.Errorf("Unexpected src/Client.lua:\n%s", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Shared", "_Index", "evaera_promise@4.0.2", "promise", "init.lua")); err != nil {
+		t.Errorf("Expected Packages to be moved to Shared: %v", err)
+	}
+	if manifest, _ := os.ReadFile(filepath.Join(dir, "bread.toml")); !strings.Contains(string(manifest), "shared_dir = \"Shared\"") {
+		t.Errorf("Expected shared_dir in bread.toml, got:\n%s", manifest)
+	}
+
+	// the Rojo mount follows the folder, a place not named after its folder is left alone
+	moved := NewInstaller(dir, nil, nil)
+	if moved == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	if *moved.SharedPath != "game.ReplicatedStorage.Shared" || *moved.ServerPath != "game.ServerScriptService.Server" {
+		t.Errorf("Unexpected [place] after the move: %s and %s", *moved.SharedPath, *moved.ServerPath)
+	}
+}
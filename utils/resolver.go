@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"sync"
+	"yoheiyayoi/bread/breadTypes"
 
 	"golang.org/x/mod/semver"
 )
@@ -18,17 +20,101 @@ type PackageMetadata struct {
 	} `json:"versions"`
 }
 
+const (
+	DefaultRegistry    = "https://github.com/UpliftGames/wally-index"
+	DefaultRegistryAPI = "https://api.wally.run"
+)
+
 var (
 	metadataCache = make(map[string][]string)
 	metadataMu    sync.Mutex
+
+	registryAPIs = make(map[string]string)
+	registryMu   sync.Mutex
 )
 
-func ResolveVersion(name, constraint string) (string, error) {
+// RegistryAPI finds the API url of a package index.
+// The wally index is known, other indexes are cloned to read the api from their config.json.
+func RegistryAPI(registry string) (string, error) {
+	if registry == "" || strings.TrimSuffix(registry, ".git") == DefaultRegistry {
+		return DefaultRegistryAPI, nil
+	}
+
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if api, ok := registryAPIs[registry]; ok {
+		return api, nil
+	}
+
+	gitMu.Lock()
+	content, err := func() (string, error) {
+		dir, err := syncGitRepo(registry, "")
+		if err != nil {
+			return "", err
+		}
+		return runGit(dir, "show", "HEAD:config.json")
+	}()
+	gitMu.Unlock()
+	if err != nil {
+		return "", fmt.Errorf("failed to read registry %s: %w", registry, err)
+	}
+
+	var config struct {
+		API string `json:"api"`
+	}
+	if err := json.Unmarshal([]byte(content), &config); err != nil || config.API == "" {
+		return "", fmt.Errorf("registry %s has no api in config.json", registry)
+	}
+
+	api := strings.TrimSuffix(config.API, "/")
+	registryAPIs[registry] = api
+	return api, nil
+}
+
+// applyOverride replaces the constraint of a registry dependency with the version forced in [overrides]
+func applyOverride(dep breadTypes.Dependency, overrides map[string]string) (breadTypes.Dependency, bool) {
+	if !dep.IsRegistry() {
+		return dep, false
+	}
+
+	version, ok := overrides[dep.Package]
+	if !ok {
+		return dep, false
+	}
+
+	dep.Version = version
+	return dep, true
+}
+
+// edgeSpec is how a dependency edge is written in bread.lock, with the version [overrides] forced on it
+// so the edge matches the package that was installed
+func (ic *InstallationContext) edgeSpec(alias string, dep breadTypes.Dependency) string {
+	if forced, ok := applyOverride(NormalizeDependency(alias, dep), ic.overrides); ok {
+		forced.Spec = ""
+		return ic.lockSpec(forced)
+	}
+	return ic.lockSpec(dep)
+}
+
+// overriddenAliases lists the dependencies whose constraint [overrides] replaces, for the lockfile
+func (ic *InstallationContext) overriddenAliases(deps map[string]breadTypes.Dependency) []string {
+	var aliases []string
+	for alias, dep := range deps {
+		if _, ok := applyOverride(NormalizeDependency(alias, dep), ic.overrides); ok {
+			aliases = append(aliases, alias)
+		}
+	}
+	sort.Strings(aliases)
+	return aliases
+}
+
+func ResolveVersion(api, name, constraint string) (string, error) {
 	// Clean up constraint
 	constraint = strings.TrimSpace(constraint)
 
 	// Fetch available versions
-	versions, err := getPackageVersions(name)
+	versions, err := getPackageVersions(api, name)
 	if err != nil {
 		return "", err
 	}
@@ -56,15 +142,17 @@ func ResolveVersion(name, constraint string) (string, error) {
 	return strings.TrimPrefix(bestMatch, "v"), nil
 }
 
-func getPackageVersions(name string) ([]string, error) {
+func getPackageVersions(api, name string) ([]string, error) {
+	key := api + "|" + name
+
 	metadataMu.Lock()
-	if versions, ok := metadataCache[name]; ok {
+	if versions, ok := metadataCache[key]; ok {
 		metadataMu.Unlock()
 		return versions, nil
 	}
 	metadataMu.Unlock()
 
-	url := fmt.Sprintf("https://api.wally.run/v1/package-metadata/%s", name)
+	url := fmt.Sprintf("%s/v1/package-metadata/%s", api, name)
 	resp, err := http.Get(url)
 	if err != nil {
 		return nil, err
@@ -86,19 +174,23 @@ func getPackageVersions(name string) ([]string, error) {
 	}
 
 	metadataMu.Lock()
-	if existing, ok := metadataCache[name]; ok {
+	if existing, ok := metadataCache[key]; ok {
 		metadataMu.Unlock()
 		return existing, nil
 	}
 
-	metadataCache[name] = versions
+	metadataCache[key] = versions
 	metadataMu.Unlock()
 
 	return versions, nil
 }
 
-func GetPackageVersion(name string) ([]string, error) {
-	return getPackageVersions(name)
+func GetPackageVersion(registry, name string) ([]string, error) {
+	api, err := RegistryAPI(registry)
+	if err != nil {
+		return nil, err
+	}
+	return getPackageVersions(api, name)
 }
 
 func MatchConstraint(version, constraint string) bool {
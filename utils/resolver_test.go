@@ -0,0 +1,83 @@
+package utils
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+func TestApplyOverride(t *testing.T) {
+	overrides := map[string]string{"evaera/promise": "4.0.2"}
+
+	tests := []struct {
+		dep        breadTypes.Dependency
+		version    string
+		overridden bool
+	}{
+		{breadTypes.Dependency{Package: "evaera/promise", Version: "^4.1.0"}, "4.0.2", true},
+		{breadTypes.Dependency{Package: "sleitnick/signal", Version: "^2.0.0"}, "^2.0.0", false},
+		{breadTypes.Dependency{Path: "../promise"}, "", false},
+	}
+	for _, tt := range tests {
+		dep, ok := applyOverride(tt.dep, overrides)
+		if ok != tt.overridden || dep.Version != tt.version {
+			t.Errorf("applyOverride(%+v) = %+v %v, expected version %q %v", tt.dep, dep, ok, tt.version, tt.overridden)
+		}
+	}
+}
+
+func TestOverriddenAliasesAndEdges(t *testing.T) {
+	ic := &InstallationContext{overrides: map[string]string{"evaera/promise": "4.0.2"}}
+	deps := map[string]breadTypes.Dependency{
+		"Promise":    {Spec: "evaera/promise@^4.1.0"},
+		"OldPromise": {Package: "evaera/promise", Version: "^3.0.0"},
+		"Signal":     {Spec: "sleitnick/signal@^2.0.0"},
+	}
+
+	if got := ic.overriddenAliases(deps); !slices.Equal(got, []string{"OldPromise", "Promise"}) {
+		t.Errorf("Expected OldPromise and Promise overridden, got %v", got)
+	}
+
+	// edges hold the forced version so they match what was installed
+	expected := [][]string{
+		{"OldPromise", "evaera/promise@4.0.2"},
+		{"Promise", "evaera/promise@4.0.2"},
+		{"Signal", "sleitnick/signal@^2.0.0"},
+	}
+	if got := ic.sortedDeps(deps); !slices.EqualFunc(got, expected, slices.Equal) {
+		t.Errorf("Expected edges %v, got %v", expected, got)
+	}
+}
+
+func TestExportWallyLockKeepsOverriddenEdges(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n\n[dependencies]\nPromise = \"evaera/promise@^4.1.0\"\n\n[overrides]\n\"evaera/promise\" = \"4.0.2\"\n",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	lockfile := breadTypes.Lockfile{Packages: []breadTypes.LockedPackage{
+		{Name: "evaera/promise", Version: "4.0.2", Dependencies: [][]string{}},
+		ic.createRootPackage(),
+	}}
+	if err := ic.saveLockfile(lockfile); err != nil {
+		t.Fatalf("saveLockfile failed: %v", err)
+	}
+
+	skipped, err := ExportWallyLock(dir)
+	if err != nil || len(skipped) != 0 {
+		t.Fatalf("Expected the overridden edge to be exported, got %v %v", skipped, err)
+	}
+	wallyLock, err := readLockfile(filepath.Join(dir, "wally.lock"))
+	if err != nil || wallyLock == nil {
+		t.Fatalf("Failed to read wally.lock: %v", err)
+	}
+	root := wallyLock.Packages[1]
+	if len(root.Dependencies) != 1 || root.Dependencies[0][1] != "evaera/promise@4.0.2" {
+		t.Errorf("Expected the edge to point at the forced version, got %v", root.Dependencies)
+	}
+}
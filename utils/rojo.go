@@ -0,0 +1,105 @@
+package utils
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultRojoProject is the project file used when [bread] rojo_project isn't set
+const DefaultRojoProject = "default.project.json"
+
+// rojoMount is where a realm folder goes in the Rojo tree
+type rojoMount struct {
+	instance []string // e.g. ReplicatedStorage, Packages
+	dir      string   // absolute folder on disk
+}
+
+func (ic *InstallationContext) rojoProjectPath() string {
+	if project := ic.Manifest.BreadConfig.RojoProject; project != "" {
+		return filepath.Join(ic.ProjectPath, project)
+	}
+	return filepath.Join(ic.ProjectPath, DefaultRojoProject)
+}
+
+// rojoMounts returns the realms in use, placed by [place] or under ReplicatedStorage / ServerScriptService
+func (ic *InstallationContext) rojoMounts() []rojoMount {
+	var devPlace *string
+	if ic.Manifest.Place != nil && ic.Manifest.Place.DevPackages != "" {
+		devPlace = &ic.Manifest.Place.DevPackages
+	}
+
+	var mounts []rojoMount
+	for _, realm := range ic.realmsInUse() {
+		dir := ic.getRealmDir(realm)
+
+		var place *string
+		service := "ReplicatedStorage"
+		switch realm {
+		case RealmShared:
+			place = ic.SharedPath
+		case RealmServer:
+			place = ic.ServerPath
+			service = "ServerScriptService"
+		case RealmDev:
+			place = devPlace
+		}
+
+		instance := []string{service, filepath.Base(dir)}
+		if place != nil {
+			instance = strings.Split(strings.TrimPrefix(*place, "game."), ".")
+		}
+		mounts = append(mounts, rojoMount{instance: instance, dir: dir})
+	}
+
+	return mounts
+}
+
+// realmsInUse returns the realms with dependencies or an installed folder
+func (ic *InstallationContext) realmsInUse() []Realm {
+	inUse := make(map[Realm]bool)
+	for _, r := range ic.dependenciesByRealm() {
+		if len(r.deps) > 0 {
+			inUse[r.realm] = true
+		}
+	}
+
+	var realms []Realm
+	for _, realm := range []Realm{RealmShared, RealmServer, RealmDev} {
+		if _, err := os.Stat(ic.getRealmDir(realm)); err == nil || inUse[realm] {
+			realms = append(realms, realm)
+		}
+	}
+	return realms
+}
+
+// SyncRojoProject points the $path of every realm folder in the Rojo project file at its folder.
+// Only those entries are written, the rest of the file is kept as it is. Returns the file and whether it changed.
+func (ic *InstallationContext) SyncRojoProject() (string, bool, error) {
+	projectPath := ic.rojoProjectPath()
+	data, err := os.ReadFile(projectPath)
+	if err != nil {
+		return projectPath, false, err
+	}
+
+	editor := newJSONEditor(data)
+	for _, mount := range ic.rojoMounts() {
+		rel, err := filepath.Rel(filepath.Dir(projectPath), mount.dir)
+		if err != nil {
+			return projectPath, false, err
+		}
+
+		path := append([]string{"tree"}, mount.instance...)
+		path = append(path, "$path")
+		if err := editor.set(path, jsonQuote(filepath.ToSlash(rel))); err != nil {
+			return projectPath, false, fmt.Errorf("failed to update %s: %w", filepath.Base(projectPath), err)
+		}
+	}
+
+	if bytes.Equal(editor.src, data) {
+		return projectPath, false, nil
+	}
+	return projectPath, true, os.WriteFile(projectPath, editor.src, 0644)
+}
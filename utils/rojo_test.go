@@ -0,0 +1,63 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncRojoProjectKeepsOtherJSON(t *testing.T) {
+	project := `{
+	"name": "game",
+	"tree": {
+		"$className": "DataModel",
+		"Workspace": {"$properties": {"Gravity": 100}, "Tags": ["a", "}"]},
+		"ReplicatedStorage": {
+			"Packages": { "$path": "Old" }
+		}
+	}
+}
+`
+	want := `{
+	"name": "game",
+	"tree": {
+		"$className": "DataModel",
+		"Workspace": {"$properties": {"Gravity": 100}, "Tags": ["a", "}"]},
+		"ReplicatedStorage": {
+			"Packages": { "$path": "Packages" }
+		},
+		"ServerScriptService": {
+			"ServerPackages": {
+				"$path": "ServerPackages"
+			}
+		}
+	}
+}
+`
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"default.project.json": project,
+		"Packages/.keep":       "",
+		"ServerPackages/.keep": "",
+		"bread.toml":           "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+
+	if _, changed, err := ic.SyncRojoProject(); err != nil || !changed {
+		t.Fatalf("SyncRojoProject failed: changed=%v err=%v", changed, err)
+	}
+
+	got, _ := os.ReadFile(filepath.Join(dir, "default.project.json"))
+	if string(got) != want {
+		t.Errorf("Unexpected project file.\nGot:\n%s\nExpected:\n%s", got, want)
+	}
+
+	if _, changed, err := ic.SyncRojoProject(); err != nil || changed {
+		t.Errorf("Expected a second sync to change nothing: changed=%v err=%v", changed, err)
+	}
+}
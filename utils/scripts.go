@@ -0,0 +1,116 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/charmbracelet/log"
+)
+
+// Lifecycle scripts, run from [scripts] when they are defined.
+// There is no prepublish, bread doesn't publish packages.
+const (
+	PreinstallScript  = "preinstall"
+	PostinstallScript = "postinstall"
+)
+
+// RunScript runs [scripts].name in the project directory, args are passed on to the command
+func (ic *InstallationContext) RunScript(name string, args []string) error {
+	command, ok := ic.Manifest.Scripts[name]
+	if !ok {
+		return fmt.Errorf("no script named %q in [scripts]", name)
+	}
+	return runScript(ic.ProjectPath, name, command, args)
+}
+
+// RunHook runs a lifecycle script if the project has one
+func (ic *InstallationContext) RunHook(name string) error {
+	if _, ok := ic.Manifest.Scripts[name]; !ok {
+		return nil
+	}
+	if err := ic.RunScript(name, nil); err != nil {
+		return fmt.Errorf("%s script failed: %w", name, err)
+	}
+	return nil
+}
+
+// runDependencyScripts runs the postinstall of installed packages listed in allow_scripts.
+// Everything else is skipped, a package could run anything on the machine.
+func (ic *InstallationContext) runDependencyScripts(realms []realmDependencies) error {
+	// a package names itself in its manifest, so it's known by what bread.lock installed instead
+	lockfile, err := readLockfile(filepath.Join(ic.lockRoot(), "bread.lock"))
+	if err != nil {
+		return err
+	}
+	installed := make(map[string]string) // _Index folder -> package name
+	if lockfile != nil {
+		for _, pkg := range lockfile.Packages {
+			installed[packageIDFileName(pkg.Name, pkg.Version)] = pkg.Name
+		}
+	}
+
+	for _, r := range realms {
+		if len(r.deps) == 0 {
+			continue
+		}
+
+		// _Index/<scope_name@version>/<name>
+		dirs, _ := filepath.Glob(filepath.Join(ic.getIndexDir(r.realm), "*", "*"))
+		for _, dir := range dirs {
+			manifest, err := readPackageManifest(dir)
+			if err != nil || manifest == nil {
+				continue
+			}
+			command, ok := manifest.Scripts[PostinstallScript]
+			if !ok {
+				continue
+			}
+
+			name, ok := installed[filepath.Base(filepath.Dir(dir))]
+			if !ok || getPackageName(name) != filepath.Base(dir) {
+				log.Warnf("Skipped the postinstall script in %s, it isn't a package in bread.lock", dir)
+				continue
+			}
+			if !slices.Contains(ic.Manifest.BreadConfig.AllowScripts, name) {
+				log.Warnf("Skipped the postinstall script of %s, add it to allow_scripts in [bread] to run it", name)
+				continue
+			}
+
+			if err := runScript(dir, name+" "+PostinstallScript, command, nil); err != nil {
+				return fmt.Errorf("postinstall script of %s failed: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
+
+// runScript runs command through the shell in dir with the terminal attached
+func runScript(dir, name, command string, args []string) error {
+	log.Infof("%s %s: %s", Info, name, command)
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		for _, arg := range args {
+			command += ` "` + strings.ReplaceAll(arg, `"`, `\"`) + `"`
+		}
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		// "$@" hands the arguments over without quoting them into the command
+		if len(args) > 0 {
+			command += ` "$@"`
+		}
+		cmd = exec.Command("sh", append([]string{"-c", command, name}, args...)...)
+	}
+
+	cmd.Dir = dir
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "BREAD_SCRIPT="+name)
+	return cmd.Run()
+}
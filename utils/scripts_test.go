@@ -0,0 +1,82 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+func TestRunScript(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts run through sh here")
+	}
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n\n[scripts]\necho = \"printf '%s|' $BREAD_SCRIPT > out.txt; printf '%s|' >> out.txt\"\n",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+
+	if err := ic.RunScript("echo", []string{"a b", "$HOME", "'c'"}); err != nil {
+		t.Fatalf("RunScript failed: %v", err)
+	}
+	data, _ := os.ReadFile(filepath.Join(dir, "out.txt"))
+	if string(data) != "echo|a b|$HOME|'c'|" {
+		t.Errorf("Arguments weren't passed through as is: %q", data)
+	}
+
+	if err := ic.RunScript("missing", nil); err == nil {
+		t.Errorf("Expected an error for a missing script")
+	}
+	if err := ic.RunHook(PreinstallScript); err != nil {
+		t.Errorf("A hook that isn't defined should do nothing, got %v", err)
+	}
+}
+
+func TestRunDependencyScriptsAllowList(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("scripts run through sh here")
+	}
+
+	dir := t.TempDir()
+	dependency := func(name string) string {
+		return "[package]\nname = \"me/" + name + "\"\nversion = \"1.0.0\"\n\n[scripts]\npostinstall = \"touch ran\"\n"
+	}
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n\n[bread]\nallow_scripts = [\"me/trusted\"]\n",
+		"Packages/_Index/me_trusted@1.0.0/trusted/bread.toml": dependency("trusted"),
+		"Packages/_Index/me_other@1.0.0/other/bread.toml":     dependency("other"),
+		// claims an allowed name in its own manifest
+		"Packages/_Index/me_evil@1.0.0/evil/bread.toml": dependency("trusted"),
+		"bread.lock": "registry = \"test\"\n\n" +
+			"[[package]]\nname = \"me/evil\"\nversion = \"1.0.0\"\ndependencies = []\n\n" +
+			"[[package]]\nname = \"me/other\"\nversion = \"1.0.0\"\ndependencies = []\n\n" +
+			"[[package]]\nname = \"me/trusted\"\nversion = \"1.0.0\"\ndependencies = []\n",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+
+	realms := []realmDependencies{{RealmShared, map[string]breadTypes.Dependency{"Trusted": {}}}}
+	if err := ic.runDependencyScripts(realms); err != nil {
+		t.Fatalf("runDependencyScripts failed: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "Packages/_Index/me_trusted@1.0.0/trusted/ran")); err != nil {
+		t.Errorf("Expected the allowed postinstall to run")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Packages/_Index/me_other@1.0.0/other/ran")); err == nil {
+		t.Errorf("Expected the postinstall of a package not in allow_scripts to be skipped")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "Packages/_Index/me_evil@1.0.0/evil/ran")); err == nil {
+		t.Errorf("Expected a package naming itself after an allowed one to be skipped")
+	}
+}
@@ -0,0 +1,227 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// SourcemapFile is what luau-lsp reads, written next to bread.toml
+const SourcemapFile = "sourcemap.json"
+
+// maxSourcemapDepth stops symlinked packages that contain themselves
+const maxSourcemapDepth = 64
+
+// SourcemapNode is one instance in a Rojo sourcemap
+type SourcemapNode struct {
+	Name      string           `json:"name"`
+	ClassName string           `json:"className"`
+	FilePaths []string         `json:"filePaths,omitempty"`
+	Children  []*SourcemapNode `json:"children,omitempty"`
+}
+
+// WriteSourcemap puts the package folders, _Index packages and link files into sourcemap.json.
+// An existing sourcemap (e.g. from rojo sourcemap) keeps everything else.
+func (ic *InstallationContext) WriteSourcemap() (string, error) {
+	path := filepath.Join(ic.ProjectPath, SourcemapFile)
+
+	root := &SourcemapNode{Name: filepath.Base(ic.ProjectPath), ClassName: "DataModel"}
+	if data, err := os.ReadFile(path); err == nil {
+		var existing SourcemapNode
+		if err := json.Unmarshal(data, &existing); err == nil && existing.ClassName == "DataModel" {
+			root = &existing
+		}
+	}
+
+	for _, mount := range ic.rojoMounts() {
+		node := ic.sourcemapPath(mount.instance[len(mount.instance)-1], mount.dir, 0)
+		if node == nil {
+			continue
+		}
+		placeSourcemapNode(root, mount.instance, node)
+	}
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		return path, err
+	}
+	return path, os.WriteFile(path, data, 0644)
+}
+
+// placeSourcemapNode replaces or adds node at the instance path, making the parents on the way
+func placeSourcemapNode(root *SourcemapNode, instance []string, node *SourcemapNode) {
+	parent := root
+	for i, name := range instance[:len(instance)-1] {
+		var next *SourcemapNode
+		for _, child := range parent.Children {
+			if child.Name == name {
+				next = child
+				break
+			}
+		}
+		if next == nil {
+			className := "Folder"
+			if i == 0 {
+				className = name // Services are named after their class
+			}
+			next = &SourcemapNode{Name: name, ClassName: className}
+			parent.Children = append(parent.Children, next)
+		}
+		parent = next
+	}
+
+	for i, child := range parent.Children {
+		if child.Name == node.Name {
+			parent.Children[i] = node
+			return
+		}
+	}
+	parent.Children = append(parent.Children, node)
+}
+
+// sourcemapPath maps a file or folder to instances the way Rojo does
+func (ic *InstallationContext) sourcemapPath(name, path string, depth int) *SourcemapNode {
+	if depth > maxSourcemapDepth {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	if !info.IsDir() {
+		className, ok := scriptClass(filepath.Base(path))
+		if !ok {
+			return nil
+		}
+		return &SourcemapNode{Name: name, ClassName: className, FilePaths: []string{ic.sourcemapFilePath(path)}}
+	}
+
+	// Packages ship a default.project.json saying what their folder turns into
+	if project := filepath.Join(path, DefaultRojoProject); fileExists(project) {
+		if node := ic.sourcemapProject(name, project, depth); node != nil {
+			return node
+		}
+	}
+
+	node := &SourcemapNode{Name: name, ClassName: "Folder"}
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return node
+	}
+
+	// init.lua turns the folder into the script itself
+	for _, entry := range entries {
+		file := entry.Name()
+		if !strings.HasPrefix(file, "init.") || entry.IsDir() {
+			continue
+		}
+		if className, ok := scriptClass(file); ok {
+			node.ClassName = className
+			node.FilePaths = append(node.FilePaths, ic.sourcemapFilePath(filepath.Join(path, file)))
+		}
+	}
+
+	for _, entry := range entries {
+		file := entry.Name()
+		if strings.HasPrefix(file, ".") || strings.HasPrefix(file, "init.") {
+			continue
+		}
+		if child := ic.sourcemapPath(instanceName(file), filepath.Join(path, file), depth+1); child != nil {
+			node.Children = append(node.Children, child)
+		}
+	}
+
+	return node
+}
+
+// sourcemapProject follows a project file, $path and the children listed in the tree
+func (ic *InstallationContext) sourcemapProject(name, projectPath string, depth int) *SourcemapNode {
+	data, err := os.ReadFile(projectPath)
+	if err != nil {
+		return nil
+	}
+
+	var project struct {
+		Tree map[string]any `json:"tree"`
+	}
+	if err := json.Unmarshal(data, &project); err != nil || project.Tree == nil {
+		return nil
+	}
+
+	node := ic.sourcemapTree(name, project.Tree, filepath.Dir(projectPath), depth)
+	node.FilePaths = append([]string{ic.sourcemapFilePath(projectPath)}, node.FilePaths...)
+	return node
+}
+
+func (ic *InstallationContext) sourcemapTree(name string, tree map[string]any, baseDir string, depth int) *SourcemapNode {
+	var node *SourcemapNode
+	if path, ok := tree["$path"].(string); ok {
+		node = ic.sourcemapPath(name, filepath.Join(baseDir, path), depth+1)
+	}
+	if node == nil {
+		node = &SourcemapNode{Name: name, ClassName: "Folder"}
+	}
+	if className, ok := tree["$className"].(string); ok {
+		node.ClassName = className
+	}
+
+	keys := make([]string, 0, len(tree))
+	for key := range tree {
+		if !strings.HasPrefix(key, "$") {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		if child, ok := tree[key].(map[string]any); ok {
+			node.Children = append(node.Children, ic.sourcemapTree(key, child, baseDir, depth+1))
+		}
+	}
+	return node
+}
+
+// scriptClass returns the instance class of a source file, false for files Rojo doesn't turn into scripts
+func scriptClass(file string) (string, bool) {
+	ext := filepath.Ext(file)
+	if ext != ".lua" && ext != ".luau" {
+		return "", false
+	}
+	switch filepath.Ext(strings.TrimSuffix(file, ext)) {
+	case ".server":
+		return "Script", true
+	case ".client":
+		return "LocalScript", true
+	}
+	return "ModuleScript", true
+}
+
+// instanceName strips .lua/.luau and the .server/.client suffixes
+func instanceName(file string) string {
+	ext := filepath.Ext(file)
+	if ext != ".lua" && ext != ".luau" {
+		return file
+	}
+	name := strings.TrimSuffix(file, ext)
+	for _, suffix := range []string{".server", ".client"} {
+		name = strings.TrimSuffix(name, suffix)
+	}
+	return name
+}
+
+// sourcemapFilePath is relative to the project, with forward slashes like Rojo writes them
+func (ic *InstallationContext) sourcemapFilePath(path string) string {
+	if rel, err := filepath.Rel(ic.ProjectPath, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return filepath.ToSlash(path)
+}
+
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
@@ -0,0 +1,69 @@
+package utils
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteSourcemapFollowsPackageProjects(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n",
+		// rojo sourcemap output for the game's own code must survive
+		"sourcemap.json":      `{"name":"game","className":"DataModel","children":[{"name":"ReplicatedStorage","className":"ReplicatedStorage","children":[{"name":"Shared","className":"Folder"}]}]}`,
+		"Packages/Signal.lua": "return require(script.Parent._Index[\"sleitnick_signal@2.0.0\"][\"signal\"])\n",
+		"Packages/_Index/sleitnick_signal@2.0.0/signal/default.project.json": `{"name": "signal", "tree": {"$path": "src"}}`,
+		"Packages/_Index/sleitnick_signal@2.0.0/signal/src/init.luau":        "return {}\n",
+		"Packages/_Index/sleitnick_signal@2.0.0/signal/src/Util.server.lua":  "",
+		"Packages/_Index/sleitnick_signal@2.0.0/signal/wally.toml":           "",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	if _, err := ic.WriteSourcemap(); err != nil {
+		t.Fatalf("WriteSourcemap failed: %v", err)
+	}
+
+	data, _ := os.ReadFile(filepath.Join(dir, SourcemapFile))
+	var root SourcemapNode
+	if err := json.Unmarshal(data, &root); err != nil {
+		t.Fatalf("Invalid sourcemap: %v", err)
+	}
+
+	find := func(node *SourcemapNode, name string) *SourcemapNode {
+		if node == nil {
+			return nil
+		}
+		for _, child := range node.Children {
+			if child.Name == name {
+				return child
+			}
+		}
+		return nil
+	}
+
+	storage := find(&root, "ReplicatedStorage")
+	if find(storage, "Shared") == nil {
+		t.Errorf("Expected the existing Shared folder to be kept")
+	}
+
+	packages := find(storage, "Packages")
+	if link := find(packages, "Signal"); link == nil || link.ClassName != "ModuleScript" {
+		t.Errorf("Expected the Signal link file, got %+v", link)
+	}
+
+	signal := find(find(find(packages, "_Index"), "sleitnick_signal@2.0.0"), "signal")
+	if signal == nil {
+		t.Fatalf("Expected the package in _Index:\n%s", data)
+	}
+	if signal.ClassName != "ModuleScript" || len(signal.FilePaths) != 2 || signal.FilePaths[1] != "Packages/_Index/sleitnick_signal@2.0.0/signal/src/init.luau" {
+		t.Errorf("Expected the package to follow its default.project.json, got %+v", signal)
+	}
+	if util := find(signal, "Util"); util == nil || util.ClassName != "Script" {
+		t.Errorf("Expected Util as a Script, got %+v", util)
+	}
+}
@@ -0,0 +1,439 @@
+package utils
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+// DefaultToolsURL is the GitHub API [tools] releases come from unless tools_url is set
+const DefaultToolsURL = "https://api.github.com"
+
+// ToolSpec is a [tools] entry, rojo = "rojo-rbx/rojo@7.4.1"
+type ToolSpec struct {
+	Name    string
+	Owner   string
+	Repo    string
+	Version string
+}
+
+// Source is the owner/repo the releases come from
+func (s ToolSpec) Source() string {
+	return s.Owner + "/" + s.Repo
+}
+
+func ParseToolSpec(name, spec string) (ToolSpec, error) {
+	source, version, found := strings.Cut(spec, "@")
+	owner, repo, ok := strings.Cut(source, "/")
+	if !found || !ok || owner == "" || repo == "" || version == "" || strings.Contains(repo, "/") {
+		return ToolSpec{}, fmt.Errorf("tool %s: expected \"owner/repo@version\", got %q", name, spec)
+	}
+	return ToolSpec{Name: name, Owner: owner, Repo: repo, Version: version}, nil
+}
+
+// ToolsResult lists what bread tools install did
+type ToolsResult struct {
+	Installed []string
+	UpToDate  []string
+}
+
+type githubRelease struct {
+	TagName string        `json:"tag_name"`
+	Assets  []githubAsset `json:"assets"`
+}
+
+type githubAsset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// toolPlatform is the platform a tool is pinned for, e.g. linux-amd64
+func toolPlatform() string {
+	return runtime.GOOS + "-" + runtime.GOARCH
+}
+
+// toolsDir is where tool binaries live, ~/.bread/tools
+func toolsDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".bread", "tools"), nil
+}
+
+// toolBinary is the path of a tool's executable, ~/.bread/tools/<owner>/<repo>/<version>/<repo>
+func toolBinary(spec ToolSpec) (string, error) {
+	dir, err := toolsDir()
+	if err != nil {
+		return "", err
+	}
+	name := spec.Repo
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return filepath.Join(dir, spec.Owner, spec.Repo, spec.Version, name), nil
+}
+
+func (ic *InstallationContext) toolSpecs() ([]ToolSpec, error) {
+	var specs []ToolSpec
+	for _, name := range sortedKeys(ic.Manifest.Tools) {
+		spec, err := ParseToolSpec(name, ic.Manifest.Tools[name])
+		if err != nil {
+			return nil, err
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// lockedTool finds the pin of a tool for this platform
+func lockedTool(tools []breadTypes.LockedTool, spec ToolSpec) *breadTypes.LockedTool {
+	for i, tool := range tools {
+		if tool.Name == spec.Name && tool.Source == spec.Source() && tool.Version == spec.Version && tool.Platform == toolPlatform() {
+			return &tools[i]
+		}
+	}
+	return nil
+}
+
+// InstallTools downloads every [tools] entry into ~/.bread/tools and pins the assets in bread.lock.
+// A tool that is already pinned has to match its checksum, pins for other platforms are kept.
+func (ic *InstallationContext) InstallTools() (*ToolsResult, error) {
+	specs, err := ic.toolSpecs()
+	if err != nil {
+		return nil, err
+	}
+
+	lockPath := filepath.Join(ic.ProjectPath, "bread.lock")
+	lockfile, err := readLockfile(lockPath)
+	if err != nil {
+		return nil, err
+	}
+	if lockfile == nil {
+		lockfile = &breadTypes.Lockfile{Registry: lockRegistry(&ic.Manifest)}
+	}
+
+	result := &ToolsResult{}
+	var tools []breadTypes.LockedTool
+	for _, spec := range specs {
+		pin := lockedTool(lockfile.Tools, spec)
+
+		binary, err := toolBinary(spec)
+		if err != nil {
+			return nil, err
+		}
+		if pin != nil && fileExists(binary) {
+			result.UpToDate = append(result.UpToDate, spec.Name)
+			tools = append(tools, *pin)
+			continue
+		}
+
+		installed, err := ic.installTool(spec, pin, binary)
+		if err != nil {
+			return nil, fmt.Errorf("tool %s: %w", spec.Name, err)
+		}
+		result.Installed = append(result.Installed, spec.Name)
+		tools = append(tools, installed)
+	}
+
+	// keep the pins other platforms wrote for tools still in [tools]
+	for _, tool := range lockfile.Tools {
+		if tool.Platform == toolPlatform() {
+			continue
+		}
+		if spec, ok := ic.Manifest.Tools[tool.Name]; ok && spec == tool.Source+"@"+tool.Version {
+			tools = append(tools, tool)
+		}
+	}
+	slices.SortStableFunc(tools, func(a, b breadTypes.LockedTool) int {
+		return strings.Compare(a.Name+" "+a.Platform, b.Name+" "+b.Platform)
+	})
+
+	if len(tools) > 0 || len(lockfile.Tools) > 0 || len(lockfile.Packages) > 0 {
+		lockfile.Tools = tools
+		if err := writeLockfileTo(lockPath, "Bread", *lockfile); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// installTool downloads the release asset for this platform and puts the tool at binary.
+// With a pin the same asset has to come back with the same checksum.
+func (ic *InstallationContext) installTool(spec ToolSpec, pin *breadTypes.LockedTool, binary string) (breadTypes.LockedTool, error) {
+	release, err := ic.fetchRelease(spec)
+	if err != nil {
+		return breadTypes.LockedTool{}, err
+	}
+
+	var asset githubAsset
+	if pin != nil {
+		i := slices.IndexFunc(release.Assets, func(a githubAsset) bool { return a.Name == pin.Asset })
+		if i < 0 {
+			return breadTypes.LockedTool{}, fmt.Errorf("pinned asset %s is gone from the %s release", pin.Asset, spec.Version)
+		}
+		asset = release.Assets[i]
+	} else {
+		var ok bool
+		if asset, ok = pickAsset(release.Assets, runtime.GOOS, runtime.GOARCH); !ok {
+			return breadTypes.LockedTool{}, fmt.Errorf("no release asset for %s in %s@%s", toolPlatform(), spec.Source(), spec.Version)
+		}
+	}
+
+	data, err := ic.download(asset.URL)
+	if err != nil {
+		return breadTypes.LockedTool{}, err
+	}
+
+	sum := sha256.Sum256(data)
+	checksum := "sha256:" + hex.EncodeToString(sum[:])
+	if pin != nil && pin.Checksum != checksum {
+		return breadTypes.LockedTool{}, fmt.Errorf("checksum mismatch for %s: bread.lock has %s, downloaded %s", asset.Name, pin.Checksum, checksum)
+	}
+
+	executable, err := extractTool(data, asset.Name, []string{spec.Repo, spec.Name})
+	if err != nil {
+		return breadTypes.LockedTool{}, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(binary), 0755); err != nil {
+		return breadTypes.LockedTool{}, err
+	}
+	if err := os.WriteFile(binary, executable, 0755); err != nil {
+		return breadTypes.LockedTool{}, err
+	}
+
+	return breadTypes.LockedTool{
+		Name:     spec.Name,
+		Source:   spec.Source(),
+		Version:  spec.Version,
+		Platform: toolPlatform(),
+		Asset:    asset.Name,
+		Checksum: checksum,
+	}, nil
+}
+
+// fetchRelease looks up the release by tag, trying v7.4.1 before 7.4.1
+func (ic *InstallationContext) fetchRelease(spec ToolSpec) (*githubRelease, error) {
+	base := ic.Manifest.BreadConfig.ToolsURL
+	if base == "" {
+		base = DefaultToolsURL
+	}
+	base = strings.TrimSuffix(base, "/")
+
+	tags := []string{spec.Version}
+	if !strings.HasPrefix(spec.Version, "v") {
+		tags = []string{"v" + spec.Version, spec.Version}
+	}
+
+	for _, tag := range tags {
+		req, err := http.NewRequest("GET", fmt.Sprintf("%s/repos/%s/%s/releases/tags/%s", base, spec.Owner, spec.Repo, tag), nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		if token := os.Getenv("GITHUB_TOKEN"); token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := ic.Client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		if resp.StatusCode == http.StatusNotFound {
+			resp.Body.Close()
+			continue
+		}
+
+		var release githubRelease
+		err = json.NewDecoder(resp.Body).Decode(&release)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch release %s of %s: %s", tag, spec.Source(), resp.Status)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read release %s of %s: %w", tag, spec.Source(), err)
+		}
+		return &release, nil
+	}
+
+	return nil, fmt.Errorf("%s has no release %s", spec.Source(), spec.Version)
+}
+
+func (ic *InstallationContext) download(url string) ([]byte, error) {
+	resp, err := ic.Client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// platformWords are how release asset names spell an OS or architecture
+var platformWords = map[string][]string{
+	"linux":   {"linux"},
+	"darwin":  {"macos", "darwin", "osx", "apple", "mac"},
+	"windows": {"windows", "win", "win64", "win32"},
+	"amd64":   {"x86_64", "x86-64", "amd64", "x64"},
+	"arm64":   {"aarch64", "arm64"},
+}
+
+// pickAsset chooses the asset for goos/goarch. The OS has to be in the name, an asset naming
+// the architecture beats one that doesn't, and checksums and signatures are never picked.
+func pickAsset(assets []githubAsset, goos, goarch string) (githubAsset, bool) {
+	best, bestScore := githubAsset{}, -1
+	for _, asset := range assets {
+		name := strings.ToLower(asset.Name)
+		if strings.HasSuffix(name, ".sha256") || strings.HasSuffix(name, ".sig") || strings.HasSuffix(name, ".asc") ||
+			strings.HasSuffix(name, ".txt") || strings.HasSuffix(name, ".json") {
+			continue
+		}
+
+		words := strings.FieldsFunc(name, func(r rune) bool {
+			return !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9')
+		})
+		if !slices.ContainsFunc(platformWords[goos], func(w string) bool { return slices.Contains(words, w) }) {
+			continue
+		}
+
+		if score := archScore(name, goos, goarch); score > bestScore {
+			best, bestScore = asset, score
+		}
+	}
+	return best, bestScore >= 0
+}
+
+// archScore ranks an asset name for goarch: 3 if it names goarch, also next to another architecture
+// as in a universal build, 1 if it names none, 0 for an x86_64 build on Apple silicon and -1 otherwise
+func archScore(name, goos, goarch string) int {
+	names := func(arch string) bool {
+		return slices.ContainsFunc(platformWords[arch], func(s string) bool { return strings.Contains(name, s) })
+	}
+
+	if names(goarch) {
+		return 3
+	}
+	for _, arch := range []string{"amd64", "arm64"} {
+		if arch == goarch || !names(arch) {
+			continue
+		}
+		if goos == "darwin" && goarch == "arm64" && arch == "amd64" {
+			return 0 // Rosetta runs x86_64 builds
+		}
+		return -1
+	}
+	return 1
+}
+
+// extractTool returns the executable from a .zip, .tar.gz or bare binary asset.
+// In an archive it's the file named after the tool, or the only file there is.
+func extractTool(data []byte, assetName string, names []string) ([]byte, error) {
+	type file struct {
+		name string
+		data func() ([]byte, error)
+	}
+	var files []file
+
+	lower := strings.ToLower(assetName)
+	switch {
+	case strings.HasSuffix(lower, ".zip"):
+		archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range archive.File {
+			if f.FileInfo().IsDir() {
+				continue
+			}
+			files = append(files, file{f.Name, func() ([]byte, error) {
+				rc, err := f.Open()
+				if err != nil {
+					return nil, err
+				}
+				defer rc.Close()
+				return io.ReadAll(rc)
+			}})
+		}
+
+	case strings.HasSuffix(lower, ".tar.gz"), strings.HasSuffix(lower, ".tgz"):
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		archive := tar.NewReader(gz)
+		for {
+			header, err := archive.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if header.Typeflag != tar.TypeReg {
+				continue
+			}
+			content, err := io.ReadAll(archive)
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file{header.Name, func() ([]byte, error) { return content, nil }})
+		}
+
+	default:
+		return data, nil
+	}
+
+	for _, f := range files {
+		base := strings.TrimSuffix(filepath.Base(filepath.FromSlash(f.name)), ".exe")
+		if slices.Contains(names, base) {
+			return f.data()
+		}
+	}
+	if len(files) == 1 {
+		return files[0].data()
+	}
+	return nil, fmt.Errorf("can't tell which file in %s is the tool", assetName)
+}
+
+// ToolPath returns the pinned executable of a [tools] entry
+func (ic *InstallationContext) ToolPath(name string) (string, error) {
+	raw, ok := ic.Manifest.Tools[name]
+	if !ok {
+		return "", fmt.Errorf("no tool named %q in [tools]", name)
+	}
+	spec, err := ParseToolSpec(name, raw)
+	if err != nil {
+		return "", err
+	}
+
+	lockfile, err := readLockfile(filepath.Join(ic.ProjectPath, "bread.lock"))
+	if err != nil {
+		return "", err
+	}
+	binary, err := toolBinary(spec)
+	if err != nil {
+		return "", err
+	}
+	if lockfile == nil || lockedTool(lockfile.Tools, spec) == nil || !fileExists(binary) {
+		return "", fmt.Errorf("%s@%s isn't installed, run bread tools install", name, spec.Version)
+	}
+	return binary, nil
+}
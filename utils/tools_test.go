@@ -0,0 +1,156 @@
+package utils
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+func TestInstallTools(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	osName := map[string]string{"darwin": "macos", "windows": "windows"}[runtime.GOOS]
+	if osName == "" {
+		osName = runtime.GOOS
+	}
+	archName := map[string]string{"amd64": "x86_64", "arm64": "aarch64"}[runtime.GOARCH]
+	if archName == "" {
+		t.Skip("no release assets for " + runtime.GOARCH)
+	}
+	assetName := "rojo-7.4.1-" + osName + "-" + archName + ".zip"
+
+	var archive bytes.Buffer
+	zw := zip.NewWriter(&archive)
+	for name, content := range map[string]string{"README.md": "readme", "rojo": "#!/bin/sh\necho rojo 7.4.1\n"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(content))
+	}
+	zw.Close()
+	assetData := archive.Bytes()
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/repos/rojo-rbx/rojo/releases/tags/v7.4.1":
+			json.NewEncoder(w).Encode(githubRelease{TagName: "v7.4.1", Assets: []githubAsset{
+				{Name: "rojo-7.4.1-plan9-" + archName + ".zip", URL: server.URL + "/download/other"},
+				{Name: assetName + ".sha256", URL: server.URL + "/download/other"},
+				{Name: assetName, URL: server.URL + "/download/" + assetName},
+			}})
+		case "/download/" + assetName:
+			w.Write(assetData)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n\n[bread]\ntools_url = \"" + server.URL + "\"\n\n[tools]\nrojo = \"rojo-rbx/rojo@7.4.1\"\n",
+		"bread.lock": "registry = \"test\"\n\n[[package]]\nname = \"me/game\"\nversion = \"0.1.0\"\ndependencies = []\n\n" +
+			"[[tool]]\nname = \"rojo\"\nsource = \"rojo-rbx/rojo\"\nversion = \"7.4.1\"\nplatform = \"plan9-amd64\"\nasset = \"rojo-plan9.zip\"\nchecksum = \"sha256:00\"\n",
+	})
+
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+
+	result, err := ic.InstallTools()
+	if err != nil {
+		t.Fatalf("InstallTools failed: %v", err)
+	}
+	if len(result.Installed) != 1 {
+		t.Errorf("Expected rojo to be installed, got %+v", result)
+	}
+
+	binary, err := ic.ToolPath("rojo")
+	if err != nil {
+		t.Fatalf("ToolPath failed: %v", err)
+	}
+	if !strings.HasPrefix(binary, filepath.Join(home, ".bread", "tools")) {
+		t.Errorf("Expected the tool under ~/.bread/tools, got %s", binary)
+	}
+	if data, _ := os.ReadFile(binary); !strings.Contains(string(data), "rojo 7.4.1") {
+		t.Errorf("Expected the rojo binary from the zip, got %q", data)
+	}
+
+	lockfile, err := readLockfile(filepath.Join(dir, "bread.lock"))
+	if err != nil || lockfile == nil {
+		t.Fatalf("Failed to read bread.lock: %v", err)
+	}
+	if len(lockfile.Packages) != 1 || len(lockfile.Tools) != 2 {
+		t.Fatalf("Expected the package and both platform pins in bread.lock, got %+v", lockfile)
+	}
+	pin := lockedTool(lockfile.Tools, ToolSpec{Name: "rojo", Owner: "rojo-rbx", Repo: "rojo", Version: "7.4.1"})
+	if pin == nil || pin.Asset != assetName || !strings.HasPrefix(pin.Checksum, "sha256:") {
+		t.Fatalf("Expected this platform's asset pinned, got %+v", lockfile.Tools)
+	}
+
+	// installing packages keeps the pins
+	if err := ic.saveLockfile(breadTypes.Lockfile{Registry: "test", Packages: lockfile.Packages}); err != nil {
+		t.Fatalf("saveLockfile failed: %v", err)
+	}
+
+	result, err = ic.InstallTools()
+	if err != nil || len(result.UpToDate) != 1 {
+		t.Errorf("Expected rojo to be up to date, got %+v %v", result, err)
+	}
+
+	// a changed asset doesn't match the pin
+	os.Remove(binary)
+	assetData = append([]byte{}, assetData...)
+	assetData[len(assetData)-1] ^= 1
+	if _, err := ic.InstallTools(); err == nil || !strings.Contains(err.Error(), "checksum mismatch") {
+		t.Errorf("Expected a checksum mismatch, got %v", err)
+	}
+}
+
+func TestPickAsset(t *testing.T) {
+	assets := []githubAsset{
+		{Name: "selene-0.27.1-linux.zip"},
+		{Name: "selene-0.27.1-macos-x86_64.zip"},
+		{Name: "selene-0.27.1-windows.zip"},
+		{Name: "stylua-darwin-aarch64.zip"},
+		{Name: "stylua-linux-aarch64.zip.sha256"},
+	}
+
+	tests := []struct {
+		goos, goarch, expected string
+	}{
+		{"linux", "amd64", "selene-0.27.1-linux.zip"},
+		{"linux", "arm64", "selene-0.27.1-linux.zip"},
+		{"darwin", "arm64", "stylua-darwin-aarch64.zip"},
+		{"darwin", "amd64", "selene-0.27.1-macos-x86_64.zip"},
+		{"windows", "amd64", "selene-0.27.1-windows.zip"},
+	}
+	for _, tt := range tests {
+		asset, ok := pickAsset(assets, tt.goos, tt.goarch)
+		if !ok || asset.Name != tt.expected {
+			t.Errorf("pickAsset(%s/%s) = %s, expected %s", tt.goos, tt.goarch, asset.Name, tt.expected)
+		}
+	}
+
+	if _, ok := pickAsset([]githubAsset{{Name: "rojo-linux-aarch64.zip"}}, "linux", "amd64"); ok {
+		t.Errorf("Expected no asset for another architecture")
+	}
+
+	// a build naming both architectures runs on either
+	both := []githubAsset{{Name: "tool-linux.zip"}, {Name: "tool-linux-x86_64-aarch64.zip"}}
+	for _, goarch := range []string{"amd64", "arm64"} {
+		if asset, ok := pickAsset(both, "linux", goarch); !ok || asset.Name != "tool-linux-x86_64-aarch64.zip" {
+			t.Errorf("pickAsset(linux/%s) = %s, expected the build naming both architectures", goarch, asset.Name)
+		}
+	}
+}
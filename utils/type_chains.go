@@ -0,0 +1,264 @@
+package utils
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// moduleTypes returns the types a module exposes: its own exports, then those of the module it
+// returns if it ends in "return require(...)". Module is set to the declaring file.
+// visiting holds the files on the current chain so require cycles stop.
+func (te *TypeExtractor) moduleTypes(packageDir, file string, visiting map[string]bool) []ExportedType {
+	if visiting[file] {
+		return nil
+	}
+	visiting[file] = true
+	defer delete(visiting, file)
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil
+	}
+	tokens := lexLuau(string(data))
+
+	// export type T = Mod.T is an export of this module, so it's found here like any other
+	types := te.extractTypes(tokens)
+	for i := range types {
+		types[i].Module = file
+	}
+
+	if returned := returnedModule(tokens, packageDir, file); returned != "" {
+		types = append(types, te.moduleTypes(packageDir, returned, visiting)...)
+	}
+
+	return types
+}
+
+// returnedModule resolves the module's final "return require(...)" or "return Name" where
+// Name is a local bound to a require. "" if the module returns anything else.
+func returnedModule(tokens []luauToken, packageDir, file string) string {
+	last := -1
+	for i, tok := range tokens {
+		if tok.is("return") {
+			last = i
+		}
+	}
+	if last < 0 || last+1 >= len(tokens) {
+		return ""
+	}
+
+	rest := tokens[last+1:]
+	if end := len(rest) - 1; rest[end].is(";") {
+		rest = rest[:end]
+	}
+
+	if len(rest) == 1 && rest[0].kind == luauName {
+		return requireBindings(tokens, packageDir, file)[rest[0].text]
+	}
+
+	target, n := parseRequire(rest, packageDir, file)
+	if n != len(rest) {
+		return "" // something after the require, e.g. a return inside a function
+	}
+	return target
+}
+
+// requireBindings maps locals declared as "local Name = require(...)" to the files they load
+func requireBindings(tokens []luauToken, packageDir, file string) map[string]string {
+	bindings := make(map[string]string)
+	for i := 0; i+3 < len(tokens); i++ {
+		if !tokens[i].is("local") || tokens[i+1].kind != luauName || !tokens[i+2].is("=") {
+			continue
+		}
+		if target, n := parseRequire(tokens[i+3:], packageDir, file); n > 0 && target != "" {
+			bindings[tokens[i+1].text] = target
+		}
+	}
+	return bindings
+}
+
+// parseRequire reads require(...) at the start of tokens and returns the file it loads, if that's
+// in the package, and the number of tokens the call took (0 if tokens don't start with one)
+func parseRequire(tokens []luauToken, packageDir, file string) (string, int) {
+	if len(tokens) < 3 || !tokens[0].is("require") || !tokens[1].is("(") {
+		return "", 0
+	}
+
+	depth := 0
+	closing := -1
+	for i, tok := range tokens[1:] {
+		if tok.is("(") {
+			depth++
+		} else if tok.is(")") {
+			depth--
+			if depth == 0 {
+				closing = i + 1
+				break
+			}
+		}
+	}
+	if closing < 0 {
+		return "", 0
+	}
+
+	target := resolveRequire(tokens[2:closing], file)
+	if target == "" || !isInside(packageDir, target) {
+		return "", closing + 1
+	}
+	return target, closing + 1
+}
+
+// resolveRequire finds the file for script.X.Parent.Y, script:WaitForChild("X"), script["X"]
+// or a "./X" string require
+func resolveRequire(args []luauToken, file string) string {
+	if len(args) == 1 && args[0].kind == luauString {
+		return resolveStringRequire(unquote(args[0].text), file)
+	}
+	if len(args) == 0 || !args[0].is("script") {
+		return ""
+	}
+
+	instance := instancePath(file)
+	for i := 1; i < len(args); {
+		switch {
+		case args[i].is(".") && i+1 < len(args) && args[i+1].kind == luauName:
+			if args[i+1].text == "Parent" {
+				instance = filepath.Dir(instance)
+			} else {
+				instance = filepath.Join(instance, args[i+1].text)
+			}
+			i += 2
+
+		case args[i].is("[") && i+2 < len(args) && args[i+1].kind == luauString && args[i+2].is("]"):
+			instance = filepath.Join(instance, unquote(args[i+1].text))
+			i += 3
+
+		case args[i].is(":") && i+3 < len(args) && args[i+2].is("(") && args[i+3].kind == luauString:
+			if method := args[i+1].text; method != "WaitForChild" && method != "FindFirstChild" {
+				return ""
+			}
+			instance = filepath.Join(instance, unquote(args[i+3].text))
+			// skip the timeout / recursive argument
+			i += 4
+			for i < len(args) && !args[i].is(")") {
+				i++
+			}
+			i++
+
+		default:
+			return ""
+		}
+	}
+
+	return moduleFile(instance)
+}
+
+// resolveStringRequire follows require-by-string paths, relative to the file or @self
+func resolveStringRequire(request, file string) string {
+	var base string
+	switch {
+	case strings.HasPrefix(request, "@self/"):
+		base = instancePath(file)
+		request = strings.TrimPrefix(request, "@self/")
+	case strings.HasPrefix(request, "./"), strings.HasPrefix(request, "../"):
+		base = filepath.Dir(file)
+	default:
+		return "" // aliases point outside the package
+	}
+	return moduleFile(filepath.Join(base, filepath.FromSlash(request)))
+}
+
+// moduleFile returns the file behind an instance: X.luau, X.lua or X/init.luau, X/init.lua
+func moduleFile(instance string) string {
+	for _, candidate := range []string{
+		instance + ".luau",
+		instance + ".lua",
+		filepath.Join(instance, "init.luau"),
+		filepath.Join(instance, "init.lua"),
+	} {
+		if fileExists(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// instancePath is the path of the instance a file becomes, an init file is its folder
+func instancePath(file string) string {
+	name := strings.TrimSuffix(file, filepath.Ext(file))
+	if filepath.Base(name) == "init" {
+		return filepath.Dir(name)
+	}
+	return name
+}
+
+// relativeInstance returns the path from one instance to another with forward slashes, "" if they're the same
+func relativeInstance(from, to string) string {
+	rel, err := filepath.Rel(from, to)
+	if err != nil || rel == "." {
+		return ""
+	}
+	return filepath.ToSlash(rel)
+}
+
+// moduleLocal is the link file local holding a module, moduleName for the entry itself
+func moduleLocal(moduleName, module string) string {
+	if module == "" {
+		return moduleName
+	}
+	var b strings.Builder
+	b.WriteString(moduleName)
+	for _, part := range strings.Split(module, "/") {
+		if part == ".." {
+			part = "Parent"
+		}
+		b.WriteByte('_')
+		for _, c := range []byte(part) {
+			if isNameChar(c) {
+				b.WriteByte(c)
+			} else {
+				b.WriteByte('_')
+			}
+		}
+	}
+	return b.String()
+}
+
+// subRequire turns the link file's require of the entry into a require of a module relative to it
+func subRequire(requirePath, module string) string {
+	inner := strings.TrimSuffix(strings.TrimPrefix(requirePath, "require("), ")")
+
+	// require-by-string
+	if strings.HasPrefix(inner, `"`) {
+		joined := path.Join(unquote(inner), module)
+		if !strings.HasPrefix(joined, ".") {
+			joined = "./" + joined
+		}
+		return "require(" + jsonQuote(joined) + ")"
+	}
+
+	for _, part := range strings.Split(module, "/") {
+		if part == ".." {
+			inner += ".Parent"
+		} else {
+			inner += "[" + jsonQuote(part) + "]"
+		}
+	}
+	return "require(" + inner + ")"
+}
+
+// isInside reports whether file is in dir
+func isInside(dir, file string) bool {
+	rel, err := filepath.Rel(dir, file)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// unquote strips the quotes of a '...' or "..." string token
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
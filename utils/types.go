@@ -1,11 +1,10 @@
 package utils
 
 import (
-	"bufio"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
 	"strings"
 )
 
@@ -13,77 +12,144 @@ import (
 type ExportedType struct {
 	Name     string
 	Generics string // generic params like <T> or <T, S>, empty if none
+	Module   string // module declaring the type, relative to the entry module ("Src", "../Types"), empty for the entry itself
 }
 
 // TypeExtractor scans Lua files for exported types
-type TypeExtractor struct {
-	exportTypePattern *regexp.Regexp
-}
+type TypeExtractor struct{}
 
 // NewTypeExtractor creates a new extractor
 func NewTypeExtractor() *TypeExtractor {
-	return &TypeExtractor{
-		// matches "export type Foo" or "export type Foo<T>"
-		// We'll parse the rest manually to handle nested generics
-		exportTypePattern: regexp.MustCompile(`^\s*export\s+type\s+(\w+)`),
-	}
+	return &TypeExtractor{}
 }
 
 // ExtractTypesFromFile reads a Lua file and pulls out all exported types
 func (te *TypeExtractor) ExtractTypesFromFile(filePath string) ([]ExportedType, error) {
-	file, err := os.Open(filePath)
+	data, err := os.ReadFile(filePath)
 	if err != nil {
 		return nil, err
 	}
-	defer file.Close()
+	return te.extractTypes(lexLuau(string(data))), nil
+}
 
+// extractTypes finds "export type Name<...>" and "export type function Name(...)" in the tokens.
+// Comments and strings are already gone, so only real declarations are left.
+func (te *TypeExtractor) extractTypes(tokens []luauToken) []ExportedType {
 	var types []ExportedType
-	scanner := bufio.NewScanner(file)
-
-	for scanner.Scan() {
-		line := scanner.Text()
-		loc := te.exportTypePattern.FindStringIndex(line)
-		if loc != nil {
-			// Found "export type Name"
-			// Now look for generics starting after the name
-			name := strings.Fields(line[loc[0]:loc[1]])[2] // export type Name -> Name is index 2
-			rest := line[loc[1]:]
-
-			generics := ""
-			if strings.HasPrefix(strings.TrimSpace(rest), "<") {
-				// Find the matching closing bracket
-				startIdx := strings.Index(rest, "<")
-				balance := 0
-				for i, r := range rest[startIdx:] {
-					if r == '<' {
-						balance++
-					} else if r == '>' {
-						balance--
-						if balance == 0 {
-							generics = rest[startIdx : startIdx+i+1]
-							break
-						}
-					}
-				}
-			}
 
-			types = append(types, ExportedType{Name: name, Generics: generics})
+	for i := 0; i+2 < len(tokens); i++ {
+		if !tokens[i].is("export") || !tokens[i+1].is("type") {
+			continue
+		}
+		// obj.export type ... isn't a declaration
+		if i > 0 && (tokens[i-1].is(".") || tokens[i-1].is(":")) {
+			continue
+		}
+
+		j := i + 2
+		function := tokens[j].is("function")
+		if function {
+			j++
+		}
+		if j >= len(tokens) || tokens[j].kind != luauName {
+			continue
+		}
+
+		exported := ExportedType{Name: tokens[j].text}
+		if function {
+			// type functions are used like generic types, one parameter per argument
+			exported.Generics = functionParams(tokens[j+1:])
+		} else {
+			exported.Generics = genericParams(tokens[j+1:])
 		}
+		types = append(types, exported)
 	}
 
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	return types
+}
+
+// genericParams returns "<...>" if the tokens start with a generic parameter list
+func genericParams(tokens []luauToken) string {
+	if len(tokens) == 0 || !tokens[0].is("<") {
+		return ""
+	}
+
+	depth := 0
+	for i, tok := range tokens {
+		switch {
+		case tok.is("<"):
+			depth++
+		case tok.is(">"):
+			depth--
+			if depth == 0 {
+				return joinTokens(tokens[:i+1])
+			}
+		}
 	}
+	return ""
+}
+
+// functionParams turns the "(a, b)" of a type function into "<a, b>"
+func functionParams(tokens []luauToken) string {
+	if len(tokens) == 0 || !tokens[0].is("(") {
+		return ""
+	}
+
+	var params []string
+	for i := 1; i < len(tokens) && !tokens[i].is(")"); i++ {
+		// the name right after ( or , is the parameter, skip any annotation
+		if tokens[i].kind == luauName && (tokens[i-1].is("(") || tokens[i-1].is(",")) {
+			params = append(params, tokens[i].text)
+		}
+	}
+	if len(params) == 0 {
+		return ""
+	}
+	return "<" + strings.Join(params, ", ") + ">"
+}
 
-	return types, nil
+// joinTokens writes tokens back on one line, a space wherever the source had whitespace or a
+// comment and after every comma
+func joinTokens(tokens []luauToken) string {
+	var b strings.Builder
+	for i, tok := range tokens {
+		if i > 0 {
+			prev := tokens[i-1]
+			if prev.is(",") || (tok.start > prev.end && !prev.is("<") && !tok.is(">") && !tok.is(",")) {
+				b.WriteByte(' ')
+			}
+		}
+		b.WriteString(tok.text)
+	}
+	return b.String()
 }
 
 // ExtractTypesFromPackage looks for the main entry file in a package and extracts types.
-// Checks init.lua, init.luau, or files matching the package name.
+// Follows the package's default.project.json, or checks init.lua, init.luau, or files matching the package name.
+// When the entry returns another module of the package, that module's types are collected too.
 func (te *TypeExtractor) ExtractTypesFromPackage(packageDir string, packageName string) ([]ExportedType, error) {
 	var allTypes []ExportedType
 
-	// check these files in order
+	if entry := findPackageEntry(packageDir, packageName); entry != "" {
+		allTypes = te.moduleTypes(packageDir, entry, map[string]bool{})
+
+		entryInstance := instancePath(entry)
+		for i := range allTypes {
+			allTypes[i].Module = relativeInstance(entryInstance, instancePath(allTypes[i].Module))
+		}
+	}
+
+	return te.deduplicateTypes(allTypes), nil
+}
+
+// findPackageEntry returns the main module of a package: what $path in its default.project.json
+// points at, or else the first of the usual files. "" if there is none.
+func findPackageEntry(packageDir string, packageName string) string {
+	if entry := projectEntry(packageDir, 0); entry != "" {
+		return entry
+	}
+
+	// no project file, check these files in order
 	candidates := []string{
 		filepath.Join(packageDir, "init.lua"),
 		filepath.Join(packageDir, "init.luau"),
@@ -95,16 +161,53 @@ func (te *TypeExtractor) ExtractTypesFromPackage(packageDir string, packageName
 
 	for _, candidate := range candidates {
 		if _, err := os.Stat(candidate); err == nil {
-			types, err := te.ExtractTypesFromFile(candidate)
-			if err != nil {
-				continue
-			}
-			allTypes = append(allTypes, types...)
-			break // found it, we're done
+			return candidate
 		}
 	}
+	return ""
+}
 
-	return te.deduplicateTypes(allTypes), nil
+// projectEntry follows $path of the default.project.json in dir to a module
+func projectEntry(dir string, depth int) string {
+	if depth > 8 {
+		return ""
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, DefaultRojoProject))
+	if err != nil {
+		return ""
+	}
+
+	var project struct {
+		Tree struct {
+			Path string `json:"$path"`
+		} `json:"tree"`
+	}
+	if err := json.Unmarshal(data, &project); err != nil || project.Tree.Path == "" {
+		return ""
+	}
+
+	target := filepath.Join(dir, project.Tree.Path)
+	info, err := os.Stat(target)
+	if err != nil {
+		return ""
+	}
+
+	if !info.IsDir() {
+		if ext := filepath.Ext(target); ext == ".lua" || ext == ".luau" {
+			return target
+		}
+		return ""
+	}
+
+	for _, init := range []string{"init.luau", "init.lua"} {
+		if _, err := os.Stat(filepath.Join(target, init)); err == nil {
+			return filepath.Join(target, init)
+		}
+	}
+
+	// $path can point at a folder with a project file of its own
+	return projectEntry(target, depth+1)
 }
 
 // deduplicateTypes removes duplicates while keeping the original order
@@ -123,25 +226,41 @@ func (te *TypeExtractor) deduplicateTypes(types []ExportedType) []ExportedType {
 }
 
 // stripGenericDefaults strips default values from generics
-// "<T, S = T>" becomes "<T, S>", "<Foo = Bar>" becomes "<Foo>"
+// "<T, S = T>" becomes "<T, S>", "<Foo = Bar>" becomes "<Foo>", "<T, U... = ...any>" becomes "<T, U...>"
 func (te *TypeExtractor) stripGenericDefaults(generics string) string {
 	if generics == "" {
 		return ""
 	}
 
-	inner := strings.TrimPrefix(generics, "<")
-	inner = strings.TrimSuffix(inner, ">")
+	tokens := lexLuau(generics)
+	if len(tokens) < 2 {
+		return generics
+	}
+	tokens = tokens[1 : len(tokens)-1] // drop the outer < >
 
-	params := strings.Split(inner, ",")
 	var cleanParams []string
-	for _, param := range params {
-		param = strings.TrimSpace(param)
-		// chop off the default value if there is one
-		if idx := strings.Index(param, "="); idx != -1 {
-			param = strings.TrimSpace(param[:idx])
+	var param []luauToken
+	inDefault := false
+	depth := 0
+	for _, tok := range tokens {
+		switch {
+		case tok.is("<") || tok.is("(") || tok.is("{") || tok.is("["):
+			depth++
+		case tok.is(">") || tok.is(")") || tok.is("}") || tok.is("]"):
+			depth--
+		case depth == 0 && tok.is(","):
+			cleanParams = append(cleanParams, joinTokens(param))
+			param, inDefault = nil, false
+			continue
+		case depth == 0 && tok.is("="):
+			// chop off the default value, it may hold commas of its own
+			inDefault = true
+		}
+		if !inDefault {
+			param = append(param, tok)
 		}
-		cleanParams = append(cleanParams, param)
 	}
+	cleanParams = append(cleanParams, joinTokens(param))
 
 	return "<" + strings.Join(cleanParams, ", ") + ">"
 }
@@ -154,7 +273,7 @@ func (te *TypeExtractor) GenerateTypeReExports(types []ExportedType, moduleName
 
 	var lines []string
 	for _, t := range types {
-		rightSide := moduleName + "." + t.Name + te.stripGenericDefaults(t.Generics)
+		rightSide := moduleLocal(moduleName, t.Module) + "." + t.Name + te.stripGenericDefaults(t.Generics)
 		line := fmt.Sprintf("export type %s%s = %s", t.Name, t.Generics, rightSide)
 		lines = append(lines, line)
 	}
@@ -175,6 +294,16 @@ func (te *TypeExtractor) GenerateLinkFileWithTypes(
 	lines = append(lines, fmt.Sprintf("--%s", fullName))
 	lines = append(lines, fmt.Sprintf("local %s = %s", moduleName, requirePath))
 
+	// types declared further down a require chain are only reachable through their own module
+	required := make(map[string]bool)
+	for _, t := range types {
+		if t.Module == "" || required[t.Module] {
+			continue
+		}
+		required[t.Module] = true
+		lines = append(lines, fmt.Sprintf("local %s = %s", moduleLocal(moduleName, t.Module), subRequire(requirePath, t.Module)))
+	}
+
 	typeExports := te.GenerateTypeReExports(types, moduleName)
 	if typeExports != "" {
 		lines = append(lines, strings.TrimSuffix(typeExports, "\n"))
@@ -74,6 +74,92 @@ return module
 	}
 }
 
+func TestExtractTypesFromFileLexer(t *testing.T) {
+	tmpDir := t.TempDir()
+	testFile := filepath.Join(tmpDir, "test.luau")
+
+	content := `
+--[[
+export type InBlockComment = string
+]]
+--[==[ export type InLevelComment = string ]==]
+local doc = [[
+export type InLongString = string
+]]
+local quoted = "export type InString = string"
+local interpolated = ` + "`{quoted} export type InInterpolated {\"}\"}`" + `
+-- export type InLineComment = string
+
+export type
+	SplitAcrossLines<
+		T,
+		U
+	> = { T | U }
+
+local x = 1; export type AfterStatement = number
+
+@native
+export type AfterAttribute = number
+
+export type Nested<T = Array<Map<string, T>>, U... = ...any> = (T) -> U...
+
+export type Pack<T...> = (T...) -> ()
+
+export type function Partial(ty, other)
+	return ty
+end
+
+export type Tight<T>={ value: T }
+
+return {}
+`
+	if err := os.WriteFile(testFile, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test file: %v", err)
+	}
+
+	types, err := NewTypeExtractor().ExtractTypesFromFile(testFile)
+	if err != nil {
+		t.Fatalf("ExtractTypesFromFile failed: %v", err)
+	}
+
+	expected := []ExportedType{
+		{Name: "SplitAcrossLines", Generics: "<T, U>"},
+		{Name: "AfterStatement"},
+		{Name: "AfterAttribute"},
+		{Name: "Nested", Generics: "<T = Array<Map<string, T>>, U... = ...any>"},
+		{Name: "Pack", Generics: "<T...>"},
+		{Name: "Partial", Generics: "<ty, other>"},
+		{Name: "Tight", Generics: "<T>"},
+	}
+	if len(types) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], types[i])
+		}
+	}
+}
+
+func TestStripGenericDefaults(t *testing.T) {
+	extractor := NewTypeExtractor()
+
+	tests := map[string]string{
+		"":                               "",
+		"<T>":                            "<T>",
+		"<T, S = T>":                     "<T, S>",
+		"<T = Array<Map<string, T>>, U>": "<T, U>",
+		"<T = { a: number, b: string }>": "<T>",
+		"<T..., U... = ...any>":          "<T..., U...>",
+		"<T = (string, number) -> ()>":   "<T>",
+	}
+	for input, expected := range tests {
+		if got := extractor.stripGenericDefaults(input); got != expected {
+			t.Errorf("stripGenericDefaults(%q) = %q, expected %q", input, got, expected)
+		}
+	}
+}
+
 func TestExtractTypesFromPackage(t *testing.T) {
 	// Create a temporary package structure
 	tmpDir := t.TempDir()
@@ -111,6 +197,107 @@ return Package
 	}
 }
 
+func TestExtractTypesFromPackageProject(t *testing.T) {
+	tmpDir := t.TempDir()
+	packageDir := filepath.Join(tmpDir, "mypackage")
+
+	// The project file points at lib/, the usual places have a decoy
+	files := map[string]string{
+		"default.project.json": `{"name": "mypackage", "tree": {"$path": "lib"}}`,
+		"lib/init.luau":        "export type Real = string\nreturn {}\n",
+		"src/init.lua":         "export type Decoy = number\nreturn {}\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(packageDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create dir: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	extractor := NewTypeExtractor()
+	types, err := extractor.ExtractTypesFromPackage(packageDir, "mypackage")
+	if err != nil {
+		t.Fatalf("ExtractTypesFromPackage failed: %v", err)
+	}
+
+	if len(types) != 1 || types[0].Name != "Real" {
+		t.Errorf("Expected the type from lib/init.luau, got %v", types)
+	}
+
+	if got := stringRequirePath(packageDir, "me_mypackage@1.0.0", "mypackage"); got != "./_Index/me_mypackage@1.0.0/mypackage/lib" {
+		t.Errorf("Unexpected require path: %s", got)
+	}
+}
+
+func TestExtractTypesFromPackageChain(t *testing.T) {
+	packageDir := t.TempDir()
+
+	// init re-exports an alias and returns Src, which returns Inner, which requires init back
+	files := map[string]string{
+		"init.lua": `local Types = require(script.Types)
+export type Config = Types.Config
+return require(script:WaitForChild("Src"))
+`,
+		"Src.lua": `export type Result<T> = { value: T }
+local Inner = require(script.Parent.Inner)
+return Inner
+`,
+		"Inner.luau": `export type Handle = number
+export type Config = string
+return require(script.Parent);
+`,
+		"Types.lua":  "export type Config = {}\nreturn {}\n",
+		"Unused.lua": "export type Unused = nil\nreturn {}\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(packageDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to create %s: %v", name, err)
+		}
+	}
+
+	extractor := NewTypeExtractor()
+	types, err := extractor.ExtractTypesFromPackage(packageDir, "mypackage")
+	if err != nil {
+		t.Fatalf("ExtractTypesFromPackage failed: %v", err)
+	}
+
+	expected := []ExportedType{
+		{Name: "Config"},
+		{Name: "Result", Generics: "<T>", Module: "Src"},
+		{Name: "Handle", Module: "Inner"},
+	}
+	if len(types) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, types)
+	}
+	for i := range expected {
+		if types[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], types[i])
+		}
+	}
+
+	result := extractor.GenerateLinkFileWithTypes(`require(script.Parent._Index["a_b@1.0.0"]["b"])`, types, "_Package", "a_b@1.0.0")
+	expectedLink := `--Bread
+--a_b@1.0.0
+local _Package = require(script.Parent._Index["a_b@1.0.0"]["b"])
+local _Package_Src = require(script.Parent._Index["a_b@1.0.0"]["b"]["Src"])
+local _Package_Inner = require(script.Parent._Index["a_b@1.0.0"]["b"]["Inner"])
+export type Config = _Package.Config
+export type Result<T> = _Package_Src.Result<T>
+export type Handle = _Package_Inner.Handle
+return _Package
+`
+	if result != expectedLink {
+		t.Errorf("Generated link file doesn't match expected.\nGot:\n%s\nExpected:\n%s", result, expectedLink)
+	}
+
+	if got := subRequire(`require("./_Index/a_b@1.0.0/b/src")`, "../Shared"); got != `require("./_Index/a_b@1.0.0/b/Shared")` {
+		t.Errorf("Unexpected string require: %s", got)
+	}
+}
+
 func TestGenerateLinkFileWithTypes(t *testing.T) {
 	extractor := NewTypeExtractor()
 
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"yoheiyayoi/bread/breadTypes"
+
+	"github.com/BurntSushi/toml"
+)
+
+// ImportResult is what an import carried over
+type ImportResult struct {
+	Dependencies int
+	Locked       int      // packages taken from the lockfile, 0 if there was none
+	Skipped      []string // what couldn't be converted
+}
+
+// ImportWally writes bread.toml and bread.lock from the wally.toml and wally.lock in projectPath.
+// The manifest is copied as written and the lockfile keeps every version, so nothing is re-resolved.
+func ImportWally(projectPath string) (*ImportResult, error) {
+	breadPath := filepath.Join(projectPath, "bread.toml")
+	if _, err := os.Stat(breadPath); err == nil {
+		return nil, fmt.Errorf("bread.toml already exists")
+	}
+
+	wallyPath := filepath.Join(projectPath, "wally.toml")
+	data, err := os.ReadFile(wallyPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read wally.toml: %w", err)
+	}
+
+	var config breadTypes.Config
+	if _, err := toml.Decode(string(data), &config); err != nil {
+		return nil, fmt.Errorf("failed to parse wally.toml: %w", err)
+	}
+
+	result := &ImportResult{
+		Dependencies: len(config.Dependencies) + len(config.ServerDependencies) + len(config.DevDependencies),
+	}
+
+	// Read the lockfile before writing anything, a broken one shouldn't leave half an import behind
+	lockfile, err := readLockfile(filepath.Join(projectPath, "wally.lock"))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.WriteFile(breadPath, data, 0644); err != nil {
+		return nil, err
+	}
+
+	// Keep the folders packages were installed into for the places it configured, requires keep working
+	if config.Place != nil {
+		editor, err := OpenManifest(breadPath)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range []struct {
+			key   string
+			dir   string
+			place string
+		}{
+			{"shared_dir", realmDir(projectPath, config.BreadConfig.PackagesDir, "Packages"), config.Place.SharedPackages},
+			{"server_dir", realmDir(projectPath, config.BreadConfig.ServerDir, "ServerPackages"), config.Place.ServerPackages},
+		} {
+			if r.place == "" {
+				continue
+			}
+			rel, err := filepath.Rel(projectPath, r.dir)
+			if err != nil {
+				return nil, err
+			}
+			if err := editor.SetString("bread", r.key, filepath.ToSlash(rel)); err != nil {
+				return nil, err
+			}
+		}
+		if err := editor.Save(); err != nil {
+			return nil, err
+		}
+	}
+
+	if lockfile != nil {
+		if err := writeLockfileTo(filepath.Join(projectPath, "bread.lock"), "Bread", *lockfile); err != nil {
+			return nil, err
+		}
+		result.Locked = len(lockfile.Packages)
+	}
+
+	return result, nil
+}
+
+// readLockfile reads a bread.lock or wally.lock, they share the format. Returns nil if there is no file.
+func readLockfile(path string) (*breadTypes.Lockfile, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil, nil
+	}
+
+	var lockfile breadTypes.Lockfile
+	if _, err := toml.DecodeFile(path, &lockfile); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	return &lockfile, nil
+}
+
+// ExportWallyLock writes wally.lock from bread.lock so Wally installs the same versions.
+// Wally locks exact versions of registry packages only, path and git packages are left out and returned.
+func ExportWallyLock(projectPath string) ([]string, error) {
+	lockfile, err := readLockfile(filepath.Join(projectPath, "bread.lock"))
+	if err != nil {
+		return nil, err
+	}
+	if lockfile == nil {
+		return nil, fmt.Errorf("no bread.lock found. Run 'bread install' first")
+	}
+
+	locked := make(map[string][]string) // name -> versions
+	for _, pkg := range lockfile.Packages {
+		if isRegistrySource(pkg.Source) {
+			locked[pkg.Name] = append(locked[pkg.Name], pkg.Version)
+		}
+	}
+
+	// Read the registry from the manifest, older bread.lock files have a placeholder
+	manifest, err := readPackageManifest(projectPath)
+	if err != nil {
+		return nil, err
+	}
+
+	var skipped []string
+	wallyLock := breadTypes.Lockfile{Registry: lockRegistry(manifest)}
+	for _, pkg := range lockfile.Packages {
+		if !isRegistrySource(pkg.Source) {
+			skipped = append(skipped, fmt.Sprintf("%s (%s)", pkg.Name, pkg.Source))
+			continue
+		}
+
+		// Wally's edges point at the exact version, bread keeps the constraint
+		deps := [][]string{}
+		for _, edge := range pkg.Dependencies {
+			alias, spec := edge[0], edge[1]
+			if strings.HasPrefix(spec, "path+") || strings.HasPrefix(spec, "git+") {
+				continue
+			}
+
+			name, constraint := ParsePackageSpec(alias, spec)
+			version := ""
+			for _, v := range locked[name] {
+				if MatchConstraint(v, constraint) {
+					version = v
+					break
+				}
+			}
+			if version == "" {
+				skipped = append(skipped, fmt.Sprintf("%s -> %s (not locked)", pkg.Name, spec))
+				continue
+			}
+			deps = append(deps, []string{alias, fmt.Sprintf("%s@%s", name, version)})
+		}
+
+		wallyLock.Packages = append(wallyLock.Packages, breadTypes.LockedPackage{
+			Name:         pkg.Name,
+			Version:      pkg.Version,
+			Dependencies: deps,
+		})
+	}
+
+	return skipped, writeLockfileTo(filepath.Join(projectPath, "wally.lock"), "Wally", wallyLock)
+}
+
+// lockRegistry is the index a lockfile records, the [package] registry or the wally index
+func lockRegistry(manifest *breadTypes.Config) string {
+	if manifest != nil && manifest.Package.Registry != "" {
+		return manifest.Package.Registry
+	}
+	return DefaultRegistry
+}
+
+// isRegistrySource reports whether a locked package came from a registry, the only kind Wally can lock
+func isRegistrySource(source string) bool {
+	return source == "" || strings.HasPrefix(source, "registry+")
+}
@@ -0,0 +1,182 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+
+	"github.com/BurntSushi/toml"
+)
+
+func TestImportWally(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"wally.toml": `[package]
+name = "me/game"
+version = "0.1.0"
+realm = "shared"
+
+[place]
+shared-packages = "game.ReplicatedStorage.Packages"
+server-packages = "game.ServerScriptService.ServerPackages"
+
+[dependencies]
+Promise = "evaera/promise@^4.0.0"
+`,
+		"wally.lock": `registry = "test"
+
+[[package]]
+name = "evaera/promise"
+version = "4.0.2"
+dependencies = []
+
+[[package]]
+name = "me/game"
+version = "0.1.0"
+dependencies = [["Promise", "evaera/promise@4.0.2"]]
+`,
+	})
+
+	result, err := ImportWally(dir)
+	if err != nil {
+		t.Fatalf("ImportWally failed: %v", err)
+	}
+	if result.Dependencies != 1 || result.Locked != 2 {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+
+	var config breadTypes.Config
+	if _, err := toml.DecodeFile(filepath.Join(dir, "bread.toml"), &config); err != nil {
+		t.Fatalf("Failed to read bread.toml: %v", err)
+	}
+	if config.BreadConfig.PackagesDir != "Packages" || config.BreadConfig.ServerDir != "ServerPackages" {
+		t.Errorf("Expected the Wally folders in [bread], got %+v", config.BreadConfig)
+	}
+	if config.Place == nil || config.Place.ServerPackages != "game.ServerScriptService.ServerPackages" {
+		t.Errorf("Expected [place] to be kept, got %+v", config.Place)
+	}
+
+	lockfile, err := readLockfile(filepath.Join(dir, "bread.lock"))
+	if err != nil || lockfile == nil {
+		t.Fatalf("Failed to read bread.lock: %v", err)
+	}
+	if len(lockfile.Packages) != 2 || lockfile.Packages[0].Version != "4.0.2" {
+		t.Errorf("Expected locked versions to be kept, got %+v", lockfile.Packages)
+	}
+
+	if _, err := ImportWally(dir); err == nil {
+		t.Errorf("Expected a second import to refuse to overwrite bread.toml")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "wally.toml")); err != nil {
+		t.Errorf("wally.toml should be left in place: %v", err)
+	}
+}
+
+func TestImportWallyKeepsConfiguredFolders(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"wally.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\nrealm = \"shared\"\n\n" +
+			"[place]\nshared-packages = \"game.ReplicatedStorage.Shared\"\nserver-packages = \"game.ServerStorage.Packages\"\n\n" +
+			"[bread]\nshared_dir = \"Shared\"\n",
+	})
+
+	if _, err := ImportWally(dir); err != nil {
+		t.Fatalf("ImportWally failed: %v", err)
+	}
+
+	var config breadTypes.Config
+	if _, err := toml.DecodeFile(filepath.Join(dir, "bread.toml"), &config); err != nil {
+		t.Fatalf("Failed to read bread.toml: %v", err)
+	}
+	if config.BreadConfig.PackagesDir != "Shared" || config.BreadConfig.ServerDir != "ServerPackages" {
+		t.Errorf("Expected the configured folders in [bread], got %+v", config.BreadConfig)
+	}
+	if config.Place == nil || config.Place.SharedPackages != "game.ReplicatedStorage.Shared" || config.Place.ServerPackages != "game.ServerStorage.Packages" {
+		t.Fatalf("Expected [place] to carry over, got %+v", config.Place)
+	}
+
+	// the imported project installs into the same places
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	if *ic.SharedPath != "game.ReplicatedStorage.Shared" || *ic.ServerPath != "game.ServerStorage.Packages" {
+		t.Errorf("Expected the places from [place], got %s and %s", *ic.SharedPath, *ic.ServerPath)
+	}
+	if ic.SharedDir != filepath.Join(dir, "Shared") || ic.ServerDir != filepath.Join(dir, "ServerPackages") {
+		t.Errorf("Expected the configured folders, got %s and %s", ic.SharedDir, ic.ServerDir)
+	}
+}
+
+func TestExportWallyLock(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\n",
+		"bread.lock": `registry = "test"
+
+[[package]]
+name = "evaera/promise"
+version = "4.0.2"
+dependencies = []
+
+[[package]]
+name = "me/game"
+version = "0.1.0"
+dependencies = [["Lib", "path+../lib"], ["Promise", "evaera/promise@^4.0.0"]]
+
+[[package]]
+name = "me/lib"
+version = "1.0.0"
+source = "path+../lib"
+dependencies = []
+`,
+	})
+
+	skipped, err := ExportWallyLock(dir)
+	if err != nil {
+		t.Fatalf("ExportWallyLock failed: %v", err)
+	}
+	if len(skipped) != 1 {
+		t.Errorf("Expected the path package to be skipped, got %v", skipped)
+	}
+
+	lockfile, err := readLockfile(filepath.Join(dir, "wally.lock"))
+	if err != nil || lockfile == nil {
+		t.Fatalf("Failed to read wally.lock: %v", err)
+	}
+	if len(lockfile.Packages) != 2 {
+		t.Fatalf("Expected 2 packages, got %+v", lockfile.Packages)
+	}
+	if lockfile.Registry != DefaultRegistry {
+		t.Errorf("Expected the wally index as registry, got %q", lockfile.Registry)
+	}
+	root := lockfile.Packages[1]
+	if len(root.Dependencies) != 1 || root.Dependencies[0][1] != "evaera/promise@4.0.2" {
+		t.Errorf("Expected the edge to point at the locked version, got %v", root.Dependencies)
+	}
+
+	// Without bread.lock, the installer picks up the versions from wally.lock
+	if err := os.Remove(filepath.Join(dir, "bread.lock")); err != nil {
+		t.Fatalf("Failed to remove bread.lock: %v", err)
+	}
+	ic := NewInstaller(dir, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	if locked := ic.Lockfile["evaera/promise"]; len(locked) != 1 || locked[0].Version != "4.0.2" {
+		t.Errorf("Expected wally.lock to seed the lockfile, got %v", locked)
+	}
+
+	// the manifest's registry is used when it has one
+	writeTree(t, dir, map[string]string{
+		"bread.toml": "[package]\nname = \"me/game\"\nversion = \"0.1.0\"\nregistry = \"https://github.com/me/index\"\n",
+		"bread.lock": "registry = \"test\"\n",
+	})
+	if _, err := ExportWallyLock(dir); err != nil {
+		t.Fatalf("ExportWallyLock failed: %v", err)
+	}
+	if lockfile, err := readLockfile(filepath.Join(dir, "wally.lock")); err != nil || lockfile.Registry != "https://github.com/me/index" {
+		t.Errorf("Expected the manifest's registry, got %+v %v", lockfile, err)
+	}
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+// workspaceMember is a package listed in the [workspace] members of the root bread.toml
+type workspaceMember struct {
+	Dir      string
+	Manifest breadTypes.Config
+}
+
+// loadWorkspace expands the member globs and reads every member manifest, keyed by package name
+func loadWorkspace(root string, patterns []string) (map[string]*workspaceMember, error) {
+	members := make(map[string]*workspaceMember)
+
+	for _, pattern := range patterns {
+		matches, err := filepath.Glob(filepath.Join(root, pattern))
+		if err != nil {
+			return nil, fmt.Errorf("invalid member pattern %q: %w", pattern, err)
+		}
+
+		for _, dir := range matches {
+			if info, err := os.Stat(dir); err != nil || !info.IsDir() || filepath.Clean(dir) == filepath.Clean(root) {
+				continue
+			}
+
+			config, err := readPackageManifest(dir)
+			if err != nil {
+				return nil, err
+			}
+			if config == nil {
+				continue
+			}
+
+			name := config.Package.Name
+			if name == "" {
+				return nil, fmt.Errorf("workspace member %s has no package name", dir)
+			}
+			if existing, ok := members[name]; ok && existing.Dir != dir {
+				return nil, fmt.Errorf("workspace members %s and %s are both named %s", existing.Dir, dir, name)
+			}
+
+			members[name] = &workspaceMember{Dir: dir, Manifest: *config}
+		}
+	}
+
+	return members, nil
+}
+
+// installTargets returns the contexts bread install works through:
+// the project itself, then every workspace member sorted by directory.
+func (ic *InstallationContext) installTargets() []*InstallationContext {
+	targets := []*InstallationContext{ic}
+	if ic.WorkspaceRoot != "" {
+		return targets
+	}
+
+	members := make([]*workspaceMember, 0, len(ic.workspace))
+	for _, member := range ic.workspace {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].Dir < members[j].Dir
+	})
+
+	for _, member := range members {
+		targets = append(targets, ic.memberContext(member))
+	}
+	return targets
+}
+
+// memberContext installs into the member's own package folders while sharing the workspace lockfile
+func (ic *InstallationContext) memberContext(member *workspaceMember) *InstallationContext {
+	config := member.Manifest.BreadConfig
+
+	return &InstallationContext{
+		Manifest:      member.Manifest,
+		Lockfile:      ic.Lockfile,
+		ProjectPath:   member.Dir,
+		SharedDir:     realmDir(member.Dir, config.PackagesDir, "Packages"),
+		ServerDir:     realmDir(member.Dir, config.ServerDir, "ServerPackages"),
+		DevDir:        realmDir(member.Dir, config.DevDir, "DevPackages"),
+		Client:        ic.Client,
+		WorkspaceRoot: ic.ProjectPath,
+		workspace:     ic.workspace,
+		overrides:     ic.overrides,
+	}
+}
+
+// resolveWorkspaceDependency turns a registry dependency on a workspace member into a path
+// dependency, so members can depend on each other without publishing.
+func (ic *InstallationContext) resolveWorkspaceDependency(dep breadTypes.Dependency) breadTypes.Dependency {
+	if !dep.IsRegistry() {
+		return dep
+	}
+
+	member, ok := ic.workspace[dep.Package]
+	if !ok || !MatchConstraint(member.Manifest.Package.Version, dep.Version) {
+		return dep
+	}
+
+	rel, err := filepath.Rel(ic.ProjectPath, member.Dir)
+	if err != nil {
+		return dep
+	}
+
+	return breadTypes.Dependency{
+		Path:     filepath.ToSlash(rel),
+		Realm:    dep.Realm,
+		Optional: dep.Optional,
+	}
+}
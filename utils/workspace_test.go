@@ -0,0 +1,130 @@
+package utils
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"yoheiyayoi/bread/breadTypes"
+)
+
+func writeWorkspace(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"bread.toml": "[package]\nname = \"me/root\"\nversion = \"0.1.0\"\n\n[workspace]\nmembers = [\"packages/*\"]\n",
+		"packages/a/bread.toml": "[package]\nname = \"me/a\"\nversion = \"1.0.0\"\n\n[bread]\nshared_dir = \"Shared\"\n\n" +
+			"[dependencies]\nB = \"me/b@^1.0.0\"\nOldB = \"me/b@^2.0.0\"\n",
+		"packages/b/wally.toml": "[package]\nname = \"me/b\"\nversion = \"1.2.0\"\nrealm = \"shared\"\n",
+		"packages/b/init.lua":   "return {}\n",
+		// neither a package nor a folder, both are skipped
+		"packages/notes/README.md": "# notes\n",
+		"packages/LICENSE":         "MIT\n",
+	})
+	return root
+}
+
+func TestLoadWorkspace(t *testing.T) {
+	root := writeWorkspace(t)
+
+	ic := NewInstaller(root, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	if len(ic.workspace) != 2 || ic.workspace["me/a"] == nil || ic.workspace["me/b"] == nil {
+		t.Fatalf("Expected members me/a and me/b, got %v", ic.workspace)
+	}
+
+	targets := ic.installTargets()
+	if len(targets) != 3 || targets[0] != ic {
+		t.Fatalf("Expected the root then both members, got %d targets", len(targets))
+	}
+	a, b := targets[1], targets[2]
+	if a.ProjectPath != filepath.Join(root, "packages", "a") || b.ProjectPath != filepath.Join(root, "packages", "b") {
+		t.Errorf("Expected members sorted by directory, got %s and %s", a.ProjectPath, b.ProjectPath)
+	}
+	if a.WorkspaceRoot != root || a.lockRoot() != root {
+		t.Errorf("Expected members to lock at the workspace root, got %s", a.lockRoot())
+	}
+	if a.SharedDir != filepath.Join(root, "packages", "a", "Shared") || b.SharedDir != filepath.Join(root, "packages", "b", "Packages") {
+		t.Errorf("Expected members to install into their own folders, got %s and %s", a.SharedDir, b.SharedDir)
+	}
+	if len(a.installTargets()) != 1 {
+		t.Errorf("Expected a member to only install itself")
+	}
+
+	// a member depending on another by name gets it by path, when the version matches
+	dep := a.resolveWorkspaceDependency(NormalizeDependency("B", a.Manifest.Dependencies["B"]))
+	if dep.Path != "../b" {
+		t.Errorf("Expected me/b to resolve to ../b, got %+v", dep)
+	}
+	if dep := a.resolveWorkspaceDependency(NormalizeDependency("OldB", a.Manifest.Dependencies["OldB"])); dep.IsPath() {
+		t.Errorf("Expected a version the member doesn't have to stay a registry dependency, got %+v", dep)
+	}
+	if dep := a.resolveWorkspaceDependency(breadTypes.Dependency{Package: "me/other", Version: "1.0.0"}); dep.IsPath() {
+		t.Errorf("Expected a package outside the workspace to stay a registry dependency, got %+v", dep)
+	}
+}
+
+func TestWorkspaceSharesOneLockfile(t *testing.T) {
+	root := writeWorkspace(t)
+
+	ic := NewInstaller(root, nil, nil)
+	if ic == nil {
+		t.Fatalf("NewInstaller failed")
+	}
+	targets := ic.installTargets()
+	a := targets[1]
+
+	session := newInstallSession(1)
+	a.installPackage("B", a.Manifest.Dependencies["B"], RealmShared, session)
+	session.wg.Wait()
+	if err := session.collectErrors(); err != nil {
+		t.Fatalf("Install failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(root, "packages", "a", "Shared", "_Index", "me_b@1.2.0", "b", "init.lua")); err != nil {
+		t.Errorf("Expected me/b linked into member a: %v", err)
+	}
+
+	if err := ic.writeLockfile(session, targets); err != nil {
+		t.Fatalf("writeLockfile failed: %v", err)
+	}
+	for _, member := range []string{"a", "b"} {
+		if _, err := os.Stat(filepath.Join(root, "packages", member, "bread.lock")); err == nil {
+			t.Errorf("Expected no bread.lock in member %s", member)
+		}
+	}
+
+	lockfile, err := readLockfile(filepath.Join(root, "bread.lock"))
+	if err != nil || lockfile == nil {
+		t.Fatalf("Failed to read the workspace bread.lock: %v", err)
+	}
+	locked := make(map[string]breadTypes.LockedPackage)
+	for _, pkg := range lockfile.Packages {
+		locked[pkg.Name] = pkg
+	}
+	if len(locked) != 3 {
+		t.Errorf("Expected the root and both members locked, got %+v", lockfile.Packages)
+	}
+	// the member installed by path keeps its own entry as a root package
+	if pkg := locked["me/b"]; pkg.Version != "1.2.0" || pkg.Source != "" {
+		t.Errorf("Unexpected entry for me/b: %+v", pkg)
+	}
+	if pkg := locked["me/a"]; len(pkg.Dependencies) != 2 || pkg.Dependencies[0][1] != "me/b@^1.0.0" {
+		t.Errorf("Unexpected entry for me/a: %+v", pkg)
+	}
+}
+
+func TestLoadWorkspaceMemberWithoutName(t *testing.T) {
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"packages/a/bread.toml": "[package]\nversion = \"1.0.0\"\n",
+	})
+
+	if _, err := loadWorkspace(root, []string{"packages/*"}); err == nil || !strings.Contains(err.Error(), "has no package name") {
+		t.Errorf("Expected an error for a member without a name, got %v", err)
+	}
+	if _, err := loadWorkspace(root, []string{"packages/["}); err == nil || !strings.Contains(err.Error(), "invalid member pattern") {
+		t.Errorf("Expected an error for a bad pattern, got %v", err)
+	}
+}